@@ -7,16 +7,89 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the LazyLinux configuration
 type Config struct {
-	PackageManager string `yaml:"package_manager"` // "dnf", "apt", or "pacman"
+	PackageManager string `yaml:"package_manager"` // "dnf", "apt", "pacman", "zypper", "apk" or "xbps"
 	FlatpakEnabled bool   `yaml:"enable_flatpak"`
+	FlatpakScope   string `yaml:"flatpak_scope,omitempty"` // "system" (default) or "user"
 	SnapEnabled    bool   `yaml:"enable_snap"`
+	SnapChannel    string `yaml:"snap_channel,omitempty"` // "stable", "candidate", "beta" or "edge"
 	RPMEnabled     bool   `yaml:"enable_rpm"`
+	AUREnabled     bool   `yaml:"enable_aur"` // Only used with pacman
+	NixEnabled     bool   `yaml:"enable_nix"`
+
+	Escalation      string   `yaml:"escalation,omitempty"`       // "sudo", "doas", "run0" or "pkexec"; detected when empty
+	DisabledPlugins []string `yaml:"disabled_plugins,omitempty"` // lazylinux-backend-<name> plugins to skip
+
+	Timeouts Timeouts      `yaml:"timeouts,omitempty"`
+	CacheTTL time.Duration `yaml:"cache_ttl,omitempty"` // How long cached package indexes are trusted; DefaultCacheTTL when unset
+
+	// Shell commands to run around transactions, keyed by hook name like
+	// "pre-install" or "post-update"
+	Hooks map[string][]string `yaml:"hooks,omitempty"`
+
+	// Short names that expand to one or more packages, managed with
+	// "lazylinux alias"
+	Aliases map[string]Alias `yaml:"aliases,omitempty"`
+}
+
+// Alias is a user-defined name for a set of packages
+type Alias struct {
+	Packages []string `yaml:"packages"`
+	Source   string   `yaml:"source,omitempty"` // Backend to use without asking, e.g. "flatpak"
+}
+
+// Timeouts limits how long each kind of operation may run, written as
+// durations like "90s" or "1h". Unset values fall back to the defaults.
+type Timeouts struct {
+	Search  time.Duration `yaml:"search,omitempty"`  // Looking packages up: resolve, search, info, list
+	Install time.Duration `yaml:"install,omitempty"` // Each install or remove
+	Update  time.Duration `yaml:"update,omitempty"`  // Each source's update
+	Clean   time.Duration `yaml:"clean,omitempty"`   // Each source's clean
+	Lock    time.Duration `yaml:"lock,omitempty"`    // Waiting for another package manager to finish
+	Hook    time.Duration `yaml:"hook,omitempty"`    // Each pre- or post-hook
+}
+
+// DefaultTimeouts are used for any timeout missing from the config
+var DefaultTimeouts = Timeouts{
+	Search:  time.Minute,
+	Install: 30 * time.Minute,
+	Update:  2 * time.Hour,
+	Clean:   30 * time.Minute,
+	Lock:    5 * time.Minute,
+	Hook:    5 * time.Minute,
+}
+
+// DefaultCacheTTL is how long a cached package index is used before
+// lookups go back to querying the sources
+const DefaultCacheTTL = 24 * time.Hour
+
+// WithDefaults fills in unset timeouts from DefaultTimeouts
+func (t Timeouts) WithDefaults() Timeouts {
+	if t.Search <= 0 {
+		t.Search = DefaultTimeouts.Search
+	}
+	if t.Install <= 0 {
+		t.Install = DefaultTimeouts.Install
+	}
+	if t.Update <= 0 {
+		t.Update = DefaultTimeouts.Update
+	}
+	if t.Clean <= 0 {
+		t.Clean = DefaultTimeouts.Clean
+	}
+	if t.Lock <= 0 {
+		t.Lock = DefaultTimeouts.Lock
+	}
+	if t.Hook <= 0 {
+		t.Hook = DefaultTimeouts.Hook
+	}
+	return t
 }
 
 // GetConfigPath returns the path to the config file
@@ -25,6 +98,35 @@ func GetConfigPath() string {
 	return filepath.Join(home, ".config", "lazylinux", "config.yaml")
 }
 
+// StateDir returns the directory for state kept between runs,
+// $XDG_STATE_HOME/lazylinux or ~/.local/state/lazylinux
+func StateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "lazylinux")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "state", "lazylinux")
+}
+
+// CacheDir returns the directory for data that can be rebuilt at any time,
+// $XDG_CACHE_HOME/lazylinux or ~/.cache/lazylinux
+func CacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "lazylinux")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "lazylinux")
+}
+
+// RuntimeDir returns the directory for files that only matter while
+// lazylinux runs, $XDG_RUNTIME_DIR/lazylinux or StateDir without it
+func RuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "lazylinux")
+	}
+	return StateDir()
+}
+
 // ConfigExists checks if the config file exists
 func ConfigExists() bool {
 	path := GetConfigPath()
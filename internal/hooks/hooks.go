@@ -0,0 +1,168 @@
+// Package hooks runs user scripts before and after package transactions.
+// Hooks come from the hooks section of config.yaml, run through sh, and
+// from executables in ~/.config/lazylinux/hooks.d/<hook>/, run in name
+// order. Each one gets the transaction as JSON on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+	"github.com/VaibhavPrakash0503/lazylinux/internal/journal"
+)
+
+// Stage says whether a hook runs before or after the transaction
+type Stage string
+
+const (
+	Pre  Stage = "pre"
+	Post Stage = "post"
+)
+
+// Operations hooks can be attached to, as in "pre-install"
+var Operations = []string{"install", "remove", "update", "clean", "undo"}
+
+// ErrHookFailed is returned when a pre-hook stops a transaction
+var ErrHookFailed = errors.New("hook failed")
+
+// errTimedOut is why a hook that ran past its timeout was killed
+var errTimedOut = errors.New("hook timed out")
+
+// Event describes the command a hook runs for. Hooks run once per
+// command, however many sources its transactions span.
+type Event struct {
+	Hook      string   `json:"hook"`      // e.g. "pre-install"
+	Operation string   `json:"operation"` // One of Operations
+	Packages  []string `json:"packages,omitempty"`
+	Command   string   `json:"command"` // As typed, e.g. "install vim htop"
+
+	// Only set for post-hooks
+	Result       string        `json:"result,omitempty"` // "success" when every transaction succeeded, otherwise "failure"
+	Transactions []Transaction `json:"transactions,omitempty"`
+}
+
+// Transaction is what the command did in one source
+type Transaction struct {
+	Source   string           `json:"source"` // Backend that ran it, e.g. "dnf" or "flatpak"
+	Packages []string         `json:"packages,omitempty"`
+	Result   string           `json:"result"` // "success" or "failure"
+	Error    string           `json:"error,omitempty"`
+	Changes  []journal.Change `json:"changes,omitempty"`
+}
+
+// Dir returns the directory holding one subdirectory of scripts per hook
+func Dir() string {
+	return filepath.Join(filepath.Dir(config.GetConfigPath()), "hooks.d")
+}
+
+// Validate reports hook names in the config that nothing would run
+func Validate(configured map[string][]string) error {
+	for name := range configured {
+		if !slices.Contains(names(), name) {
+			return fmt.Errorf("unknown hook %q in %s", name, config.GetConfigPath())
+		}
+	}
+	return nil
+}
+
+// Run runs the stage's hooks for event.Operation, killing any that takes
+// longer than timeout. A failing pre-hook stops the rest and returns
+// ErrHookFailed; post-hooks all run, and their failures are returned
+// together.
+func Run(ctx context.Context, configured map[string][]string, timeout time.Duration, stage Stage, event Event) error {
+	event.Hook = string(stage) + "-" + event.Operation
+	hooks := find(configured, event.Hook)
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	input, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not encode hook input: %v", err)
+	}
+
+	var failures []error
+	for _, hook := range hooks {
+		fmt.Printf("🪝 Running %s hook: %s\n", event.Hook, hook.name)
+
+		hookCtx, cancel := context.WithTimeoutCause(ctx, timeout, errTimedOut)
+		cmd := hook.command(hookCtx)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), "LAZYLINUX_HOOK="+event.Hook)
+
+		// A hook runs in its own process group, so a timeout kills what
+		// it started as well, not just sh
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+
+		err := cmd.Run()
+		if context.Cause(hookCtx) == errTimedOut {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+
+		if err != nil {
+			failure := fmt.Errorf("%w: %s %s: %v", ErrHookFailed, event.Hook, hook.name, err)
+			if stage == Pre {
+				return failure
+			}
+			failures = append(failures, failure)
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// hook is one configured command or hooks.d script
+type hook struct {
+	name  string // What to call it in messages
+	shell string // Command line from config.yaml
+	path  string // Script in hooks.d
+}
+
+func (h hook) command(ctx context.Context) *exec.Cmd {
+	if h.path != "" {
+		return exec.CommandContext(ctx, h.path)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", h.shell)
+}
+
+// find lists the hooks for name, config.yaml ones first
+func find(configured map[string][]string, name string) []hook {
+	var hooks []hook
+	for _, line := range configured[name] {
+		hooks = append(hooks, hook{name: line, shell: line})
+	}
+
+	// ReadDir sorts by name, so "10-snapshot" runs before "20-notify"
+	entries, _ := os.ReadDir(filepath.Join(Dir(), name))
+	for _, entry := range entries {
+		path := filepath.Join(Dir(), name, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+			continue // Not executable, e.g. a README or a disabled script
+		}
+		hooks = append(hooks, hook{name: entry.Name(), path: path})
+	}
+	return hooks
+}
+
+// names lists every valid hook name
+func names() []string {
+	var all []string
+	for _, op := range Operations {
+		all = append(all, string(Pre)+"-"+op, string(Post)+"-"+op)
+	}
+	return all
+}
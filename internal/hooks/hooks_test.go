@@ -0,0 +1,169 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/journal"
+)
+
+// useHome points the config, and so hooks.d, at a temporary home for the
+// rest of the test and returns a file hooks can write to
+func useHome(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	out := filepath.Join(t.TempDir(), "out")
+	t.Setenv("HOOK_OUT", out)
+	return out
+}
+
+// writeScript adds an executable hooks.d script for hook
+func writeScript(t *testing.T, hook, name, script string, mode os.FileMode) {
+	t.Helper()
+
+	dir := filepath.Join(Dir(), hook)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readOut returns what the hooks wrote to $HOOK_OUT
+func readOut(t *testing.T, out string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(out)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunInput(t *testing.T) {
+	out := useHome(t)
+	configured := map[string][]string{
+		"post-install": {`{ echo "$LAZYLINUX_HOOK"; cat; } > "$HOOK_OUT"`},
+	}
+
+	event := Event{
+		Operation: "install",
+		Packages:  []string{"htop", "org.gimp.GIMP"},
+		Command:   "install htop org.gimp.GIMP",
+		Result:    "failure",
+		Transactions: []Transaction{
+			{Source: "dnf", Packages: []string{"htop"}, Result: "success", Changes: []journal.Change{{Name: "htop", After: "3.3.0-3.fc40"}}},
+			{Source: "flatpak", Packages: []string{"org.gimp.GIMP"}, Result: "failure", Error: "flatpak install failed"},
+		},
+	}
+	if err := Run(context.Background(), configured, time.Minute, Post, event); err != nil {
+		t.Fatal(err)
+	}
+
+	env, input, _ := strings.Cut(readOut(t, out), "\n")
+	if env != "post-install" {
+		t.Errorf("LAZYLINUX_HOOK = %q, want %q", env, "post-install")
+	}
+
+	var got Event
+	if err := json.Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("hook input %q: %v", input, err)
+	}
+	event.Hook = "post-install"
+	if !reflect.DeepEqual(got, event) {
+		t.Errorf("hook input =\n%+v\nwant\n%+v", got, event)
+	}
+}
+
+func TestRunOrder(t *testing.T) {
+	out := useHome(t)
+	configured := map[string][]string{
+		"pre-update":  {`echo config >> "$HOOK_OUT"`},
+		"post-update": {`echo post >> "$HOOK_OUT"`},
+	}
+	writeScript(t, "pre-update", "20-notify", `echo 20 >> "$HOOK_OUT"`, 0o755)
+	writeScript(t, "pre-update", "10-snapshot", `echo 10 >> "$HOOK_OUT"`, 0o755)
+	writeScript(t, "pre-update", "README", `echo readme >> "$HOOK_OUT"`, 0o644)
+
+	if err := Run(context.Background(), configured, time.Minute, Pre, Event{Operation: "update"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := readOut(t, out), "config\n10\n20\n"; got != want {
+		t.Errorf("hooks wrote %q, want %q", got, want)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage Stage
+		want  string
+	}{
+		{name: "pre-hook stops the rest", stage: Pre, want: "first\n"},
+		{name: "post-hooks all run", stage: Post, want: "first\nthird\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := useHome(t)
+			hook := string(tt.stage) + "-remove"
+			configured := map[string][]string{
+				hook: {`echo first >> "$HOOK_OUT"; exit 3`, "false", `echo third >> "$HOOK_OUT"`},
+			}
+
+			err := Run(context.Background(), configured, time.Minute, tt.stage, Event{Operation: "remove"})
+			if !errors.Is(err, ErrHookFailed) || !strings.Contains(err.Error(), "exit status 3") {
+				t.Errorf("Run() = %v, want %v naming the failed hook", err, ErrHookFailed)
+			}
+			if got := readOut(t, out); got != tt.want {
+				t.Errorf("hooks wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	out := useHome(t)
+	configured := map[string][]string{
+		"post-clean": {"sleep 30", `echo next >> "$HOOK_OUT"`},
+	}
+
+	start := time.Now()
+	err := Run(context.Background(), configured, 100*time.Millisecond, Post, Event{Operation: "clean"})
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Run() took %s, want the hung hook killed", elapsed)
+	}
+	if !errors.Is(err, ErrHookFailed) || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Run() = %v, want %v after the timeout", err, ErrHookFailed)
+	}
+	if got := readOut(t, out); got != "next\n" {
+		t.Errorf("hooks wrote %q, want the next hook to run", got)
+	}
+}
+
+func TestRunWithoutHooks(t *testing.T) {
+	useHome(t)
+
+	if err := Run(context.Background(), nil, time.Minute, Pre, Event{Operation: "install"}); err != nil {
+		t.Errorf("Run() = %v with nothing configured", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(map[string][]string{"pre-install": nil, "post-undo": nil}); err != nil {
+		t.Errorf("Validate() = %v for known hooks", err)
+	}
+	if err := Validate(map[string][]string{"pre-upgrade": nil}); err == nil {
+		t.Error("Validate() accepted pre-upgrade")
+	}
+}
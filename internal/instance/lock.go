@@ -0,0 +1,244 @@
+// Package instance keeps concurrent lazylinux runs from stepping on each
+// other. Commands that change the system or the config files take an
+// exclusive lock; read-only commands take a shared one, so any number of
+// them can run together but never alongside a change.
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// Mode says whether a lock may be shared with other runs
+type Mode int
+
+const (
+	// Shared locks are for commands that only read, like list
+	Shared Mode = iota
+	// Exclusive locks are for commands that install, remove or write config
+	Exclusive
+)
+
+// ErrBusy is returned when another lazylinux run holds the lock
+var ErrBusy = errors.New("another lazylinux command is running")
+
+// Lock is a held instance lock. The kernel drops it when the process
+// exits, so a crashed run never leaves it behind.
+type Lock struct {
+	file *os.File
+	mode Mode
+}
+
+// Holder is the run recorded as holding an exclusive lock
+type Holder struct {
+	PID     int
+	Command string // e.g. "install vim"
+}
+
+func (h Holder) String() string {
+	if h.PID == 0 {
+		return "another lazylinux command"
+	}
+	return fmt.Sprintf("lazylinux %s (pid %d)", h.Command, h.PID)
+}
+
+// lockName is the lock file's name in whichever directory holds it
+const lockName = "lazylinux.lock"
+
+// systemLockDir is shared by every user, so a run under sudo and a plain
+// run see each other's locks
+var systemLockDir = "/run/lock"
+
+// openLockFile opens the lock file in systemLockDir, falling back to the
+// per-user runtime directory where that can't be used. In the fallback,
+// runs as different users (such as under sudo) don't block each other.
+func openLockFile() (*os.File, error) {
+	if file, err := openSharedFile(filepath.Join(systemLockDir, lockName)); err == nil {
+		return file, nil
+	}
+
+	dir := config.RuntimeDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create lock directory: %v", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, lockName), os.O_RDWR|os.O_CREATE|syscall.O_NOFOLLOW, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %v", err)
+	}
+	return file, nil
+}
+
+// openSharedFile opens a lock file every user can lock, creating it when
+// missing. An existing file is opened without O_CREAT, which sticky
+// directories like /run/lock refuse on another user's file.
+func openSharedFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOFOLLOW, 0)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return file, err
+	}
+
+	file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL|syscall.O_NOFOLLOW, 0o666)
+	if errors.Is(err, fs.ErrExist) {
+		// Another run created it first
+		return os.OpenFile(path, os.O_RDWR|syscall.O_NOFOLLOW, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The umask would keep other users out
+	if err := file.Chmod(0o666); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Acquire takes the instance lock for command, waiting up to wait for
+// another run to finish. A wait of 0 fails straight away with ErrBusy.
+func Acquire(ctx context.Context, mode Mode, command string, wait time.Duration) (*Lock, error) {
+	file, err := openLockFile()
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if mode == Exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	held, err := tryLock(file, how)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if !held {
+		if err := waitLock(ctx, file, how, wait); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	lock := &Lock{file: file, mode: mode}
+	if mode == Exclusive {
+		// Whatever was recorded belonged to a run that has finished
+		lock.record(command)
+	}
+	return lock, nil
+}
+
+// Release drops the lock. Exiting does the same.
+func (l *Lock) Release() error {
+	var errs []error
+	if l.mode == Exclusive {
+		if err := l.file.Truncate(0); err != nil {
+			errs = append(errs, fmt.Errorf("could not clear lock holder: %v", err))
+		}
+	}
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		errs = append(errs, fmt.Errorf("could not unlock %s: %v", l.file.Name(), err))
+	}
+	if err := l.file.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// record writes who holds an exclusive lock, for other runs to report
+func (l *Lock) record(command string) {
+	l.file.Truncate(0)
+	l.file.WriteAt([]byte(fmt.Sprintf("%d\n%s\n", os.Getpid(), command)), 0)
+}
+
+// readHolder reads the recorded holder of the lock. Records naming a
+// process that no longer exists are stale and ignored, as are shared
+// holders, which record nothing.
+func readHolder(file *os.File) Holder {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 4096))
+	if err != nil {
+		return Holder{}
+	}
+
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	pid, err := strconv.Atoi(lines[0])
+	if err != nil || !processAlive(pid) {
+		return Holder{}
+	}
+
+	holder := Holder{PID: pid}
+	if len(lines) > 1 {
+		holder.Command = lines[1]
+	}
+	return holder
+}
+
+// tryLock takes the lock if it is free, without blocking
+func tryLock(file *os.File, how int) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, syscall.EWOULDBLOCK):
+		return false, nil
+	}
+	return false, fmt.Errorf("could not lock %s: %v", file.Name(), err)
+}
+
+// waitLock polls for the lock until it is free, ctx is cancelled or wait
+// runs out
+func waitLock(ctx context.Context, file *os.File, how int, wait time.Duration) error {
+	holder := readHolder(file)
+	if wait <= 0 {
+		return busyError(holder, "")
+	}
+
+	fmt.Printf("⏳ %s is running, waiting up to %s...\n", holder, wait)
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return busyError(readHolder(file), fmt.Sprintf(" after waiting %s", wait))
+		case <-ticker.C:
+			if held, err := tryLock(file, how); err != nil || held {
+				return err
+			}
+		}
+	}
+}
+
+// busyError names the run holding the lock when it is known
+func busyError(holder Holder, suffix string) error {
+	if holder.PID == 0 {
+		return fmt.Errorf("%w%s", ErrBusy, suffix)
+	}
+	return fmt.Errorf("%w: %s%s", ErrBusy, holder, suffix)
+}
+
+// processAlive reports whether pid is a running process. EPERM means it
+// exists but belongs to another user.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
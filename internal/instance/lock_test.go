@@ -0,0 +1,207 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useLockDir keeps the shared lock file in a temporary directory for the
+// rest of the test and returns it
+func useLockDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	previous := systemLockDir
+	systemLockDir = dir
+	t.Cleanup(func() { systemLockDir = previous })
+	return dir
+}
+
+// acquire takes the lock without waiting, failing the test on error
+func acquire(t *testing.T, mode Mode, command string) *Lock {
+	t.Helper()
+
+	lock, err := Acquire(context.Background(), mode, command, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lock.Release() })
+	return lock
+}
+
+func TestAcquireContention(t *testing.T) {
+	tests := []struct {
+		name     string
+		held     Mode
+		wanted   Mode
+		wantBusy bool
+	}{
+		{name: "shared with shared", held: Shared, wanted: Shared, wantBusy: false},
+		{name: "exclusive while shared", held: Shared, wanted: Exclusive, wantBusy: true},
+		{name: "shared while exclusive", held: Exclusive, wanted: Shared, wantBusy: true},
+		{name: "exclusive while exclusive", held: Exclusive, wanted: Exclusive, wantBusy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLockDir(t)
+			acquire(t, tt.held, "install vim")
+
+			lock, err := Acquire(context.Background(), tt.wanted, "list", 0)
+			if lock != nil {
+				lock.Release()
+			}
+			if busy := errors.Is(err, ErrBusy); busy != tt.wantBusy {
+				t.Errorf("Acquire() = %v, want busy %v", err, tt.wantBusy)
+			}
+		})
+	}
+}
+
+func TestAcquireNamesHolder(t *testing.T) {
+	useLockDir(t)
+	acquire(t, Exclusive, "install vim")
+
+	_, err := Acquire(context.Background(), Exclusive, "remove vim", 0)
+	want := "lazylinux install vim (pid " + strconv.Itoa(os.Getpid()) + ")"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Acquire() = %v, want it to name %s", err, want)
+	}
+}
+
+func TestAcquireWaits(t *testing.T) {
+	useLockDir(t)
+	held, err := Acquire(context.Background(), Exclusive, "update", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		held.Release()
+	}()
+
+	lock, err := Acquire(context.Background(), Exclusive, "install vim", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() = %v, want the lock once it is released", err)
+	}
+	lock.Release()
+}
+
+func TestAcquireTimesOut(t *testing.T) {
+	useLockDir(t)
+	acquire(t, Exclusive, "update")
+
+	_, err := Acquire(context.Background(), Exclusive, "install vim", 300*time.Millisecond)
+	if !errors.Is(err, ErrBusy) || !strings.Contains(err.Error(), "after waiting 300ms") {
+		t.Errorf("Acquire() = %v, want %v after waiting", err, ErrBusy)
+	}
+}
+
+func TestAcquireCancelled(t *testing.T) {
+	useLockDir(t)
+	acquire(t, Exclusive, "update")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := Acquire(ctx, Exclusive, "install vim", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	dir := useLockDir(t)
+
+	lock, err := Acquire(context.Background(), Exclusive, "install vim", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, lockName))
+	if err != nil || len(data) != 0 {
+		t.Errorf("lock file holds %q after Release(), want it cleared", data)
+	}
+
+	again := acquire(t, Exclusive, "remove vim")
+	if again == nil {
+		t.Fatal("Acquire() failed after Release()")
+	}
+
+	if err := lock.Release(); err == nil {
+		t.Error("Release() succeeded twice")
+	}
+}
+
+func TestStaleHolder(t *testing.T) {
+	dir := useLockDir(t)
+
+	// A crashed run leaves its record behind but not its lock
+	if err := os.WriteFile(filepath.Join(dir, lockName), []byte("2147483647\nupdate\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	lock := acquire(t, Shared, "list")
+	if holder := readHolder(lock.file); holder.PID != 0 {
+		t.Errorf("readHolder() = %v, want no holder for a dead process", holder)
+	}
+}
+
+func TestSharedLockFile(t *testing.T) {
+	dir := useLockDir(t)
+	acquire(t, Shared, "list")
+
+	info, err := os.Stat(filepath.Join(dir, lockName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o666 {
+		t.Errorf("lock file mode = %v, want every user to be able to lock it", mode)
+	}
+}
+
+func TestLockFileFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+	}{
+		{
+			name: "missing lock directory",
+			setup: func(t *testing.T, dir string) {
+				systemLockDir = filepath.Join(dir, "missing")
+			},
+		},
+		{
+			name: "symlinked lock file",
+			setup: func(t *testing.T, dir string) {
+				target := filepath.Join(t.TempDir(), "target")
+				if err := os.Symlink(target, filepath.Join(dir, lockName)); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useLockDir(t)
+			runtime := t.TempDir()
+			t.Setenv("XDG_RUNTIME_DIR", runtime)
+			tt.setup(t, dir)
+
+			lock := acquire(t, Exclusive, "install vim")
+			if want := filepath.Join(runtime, "lazylinux", lockName); lock.file.Name() != want {
+				t.Errorf("locked %s, want the per-user %s", lock.file.Name(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package journal records the transactions lazylinux runs, so they can be
+// reviewed with "lazylinux history" and reversed with "lazylinux undo".
+// Entries are appended as JSON lines to $XDG_STATE_HOME/lazylinux/journal.jsonl.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// Entry is one transaction against one source
+type Entry struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Command   string    `json:"command"` // As typed, e.g. "install vim htop"
+	Action    string    `json:"action"`  // "install", "remove", "update" or "undo"
+	Source    string    `json:"source"`  // Backend that ran it, e.g. "dnf" or "flatpak"
+	Requested []string  `json:"requested,omitempty"`
+	Changes   []Change  `json:"changes,omitempty"`
+	Recorded  bool      `json:"recorded,omitempty"` // Installed versions were listed before and after, so Changes is complete
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	Undoes    int       `json:"undoes,omitempty"` // Entry an undo reversed
+}
+
+// Change is a package whose installed version changed. An empty Before
+// means it was installed, an empty After that it was removed.
+type Change struct {
+	Name   string `json:"name"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// Installed reports whether the transaction added the package
+func (c Change) Installed() bool {
+	return c.Before == "" && c.After != ""
+}
+
+// Removed reports whether the transaction removed the package
+func (c Change) Removed() bool {
+	return c.Before != "" && c.After == ""
+}
+
+// Path returns the journal file's location
+func Path() string {
+	return filepath.Join(config.StateDir(), "journal.jsonl")
+}
+
+// Load reads every entry, oldest first. A missing journal is empty.
+func Load() ([]Entry, error) {
+	file, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16<<20) // Updates can touch thousands of packages
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("could not parse journal line %d: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read journal: %v", err)
+	}
+
+	return entries, nil
+}
+
+// Find returns the entry with the given ID
+func Find(entries []Entry, id int) (*Entry, error) {
+	for _, entry := range entries {
+		if entry.ID == id {
+			return &entry, nil
+		}
+	}
+	return nil, fmt.Errorf("no transaction #%d in the history", id)
+}
+
+// UndoneBy returns the ID of the successful undo of entry id, or 0
+func UndoneBy(entries []Entry, id int) int {
+	for _, entry := range entries {
+		if entry.Undoes == id && entry.Success {
+			return entry.ID
+		}
+	}
+	return 0
+}
+
+// Append numbers entry and adds it to the journal. Callers hold the
+// exclusive instance lock, so IDs can't collide.
+func Append(entry *Entry) error {
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("could not encode journal entry: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		return fmt.Errorf("could not create state directory: %v", err)
+	}
+	file, err := os.OpenFile(Path(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not open journal: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not write journal: %v", err)
+	}
+	return nil
+}
+
+// Diff compares installed versions before and after a transaction and
+// returns the packages that changed, sorted by name
+func Diff(before, after map[string]string) []Change {
+	changes := []Change{}
+	for name, old := range before {
+		if current := after[name]; current != old {
+			changes = append(changes, Change{Name: name, Before: old, After: current})
+		}
+	}
+	for name, current := range after {
+		if _, existed := before[name]; !existed {
+			changes = append(changes, Change{Name: name, After: current})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
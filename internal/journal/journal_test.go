@@ -0,0 +1,132 @@
+package journal
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after map[string]string
+		want          []Change
+	}{
+		{
+			name:   "nothing changed",
+			before: map[string]string{"vim": "9.1.544-1"},
+			after:  map[string]string{"vim": "9.1.544-1"},
+			want:   []Change{},
+		},
+		{
+			name:   "installed",
+			before: map[string]string{"vim": "9.1.544-1"},
+			after:  map[string]string{"vim": "9.1.544-1", "htop": "3.3.0-3", "gpm-libs": "1.20.7-46"},
+			want: []Change{
+				{Name: "gpm-libs", After: "1.20.7-46"},
+				{Name: "htop", After: "3.3.0-3"},
+			},
+		},
+		{
+			name:   "removed",
+			before: map[string]string{"vim": "9.1.544-1", "nano": "7.2-6"},
+			after:  map[string]string{"vim": "9.1.544-1"},
+			want:   []Change{{Name: "nano", Before: "7.2-6"}},
+		},
+		{
+			name:   "upgraded and downgraded",
+			before: map[string]string{"curl": "8.6.0-8", "less": "661-1"},
+			after:  map[string]string{"curl": "8.6.0-10", "less": "643-4"},
+			want: []Change{
+				{Name: "curl", Before: "8.6.0-8", After: "8.6.0-10"},
+				{Name: "less", Before: "661-1", After: "643-4"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeKind(t *testing.T) {
+	tests := []struct {
+		change             Change
+		installed, removed bool
+	}{
+		{Change{Name: "htop", After: "3.3.0-3"}, true, false},
+		{Change{Name: "nano", Before: "7.2-6"}, false, true},
+		{Change{Name: "curl", Before: "8.6.0-8", After: "8.6.0-10"}, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.Installed(); got != tt.installed {
+			t.Errorf("%+v Installed() = %v, want %v", tt.change, got, tt.installed)
+		}
+		if got := tt.change.Removed(); got != tt.removed {
+			t.Errorf("%+v Removed() = %v, want %v", tt.change, got, tt.removed)
+		}
+	}
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if entries, err := Load(); entries != nil || err != nil {
+		t.Fatalf("Load() = %+v, %v before anything was recorded, want nothing", entries, err)
+	}
+
+	install := &Entry{Command: "install htop", Action: "install", Source: "dnf", Requested: []string{"htop"}, Changes: []Change{{Name: "htop", After: "3.3.0-3"}}, Recorded: true, Success: true}
+	undo := &Entry{Command: "undo 1", Action: "undo", Source: "dnf", Changes: []Change{{Name: "htop", Before: "3.3.0-3"}}, Recorded: true, Success: true, Undoes: 1}
+	for _, entry := range []*Entry{install, undo} {
+		if err := Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if install.ID != 1 || undo.ID != 2 {
+		t.Errorf("Append() numbered the entries %d and %d, want 1 and 2", install.ID, undo.ID)
+	}
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !reflect.DeepEqual(entries[0].Changes, install.Changes) || !entries[0].Time.Equal(install.Time) {
+		t.Fatalf("Load() = %+v, want the appended entries", entries)
+	}
+
+	if entry, err := Find(entries, 2); err != nil || entry.Undoes != 1 {
+		t.Errorf("Find(2) = %+v, %v, want the undo", entry, err)
+	}
+	if _, err := Find(entries, 3); err == nil {
+		t.Error("Find(3) succeeded for a missing entry")
+	}
+	if id := UndoneBy(entries, 1); id != 2 {
+		t.Errorf("UndoneBy(1) = %d, want 2", id)
+	}
+	if id := UndoneBy(entries, 2); id != 0 {
+		t.Errorf("UndoneBy(2) = %d, want 0", id)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	if err := Append(&Entry{Action: "install", Success: true}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(Path(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("{\"id\": 2,\n")
+	file.Close()
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() succeeded on a truncated line")
+	}
+}
@@ -0,0 +1,316 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+type APK struct{}
+
+func NewAPK() *APK {
+	return &APK{}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "apk",
+		DisplayName:  "APK",
+		Icon:         "📦",
+		Native:       true,
+		Priority:     50,
+		Capabilities: CapSearch | CapInstallReason,
+		Detect:       func() bool { return commandExists("apk") },
+		New:          func(cfg *config.Config) PackageManager { return NewAPK() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (a *APK) Name() string {
+	return "apk"
+}
+
+func (a *APK) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// APK command: apk add <packages>
+	cmd := privilegedCommand(ctx, "apk", append([]string{"add"}, packages...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// InstallVersions pins each package to its version with "name=version".
+// Only versions still in a repository or the local cache can be installed.
+func (a *APK) InstallVersions(ctx context.Context, versions map[string]string) error {
+	cmd := privilegedCommand(ctx, "apk", append([]string{"add"}, versionTargets(versions, "=")...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (a *APK) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// APK command: apk del <packages> (also drops now-unneeded dependencies)
+	cmd := privilegedCommand(ctx, "apk", append([]string{"del"}, packages...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (a *APK) Update(ctx context.Context) error {
+	// First: apk update (refresh repository indexes)
+	updateCmd := privilegedCommand(ctx, "apk", "update")
+	updateCmd.Stdout = os.Stdout
+	updateCmd.Stderr = os.Stderr
+	err := updateCmd.Run()
+	if err != nil {
+		return err
+	}
+
+	// Second: apk upgrade
+	upgradeCmd := privilegedCommand(ctx, "apk", "upgrade")
+	upgradeCmd.Stdout = os.Stdout
+	upgradeCmd.Stderr = os.Stderr
+	return upgradeCmd.Run()
+}
+
+func (a *APK) Clean(ctx context.Context) error {
+	// apk only keeps a package cache when /etc/apk/cache is set up
+	fmt.Println("🧹 Cleaning package cache...")
+	if _, err := os.Stat(hostPath("/etc/apk/cache")); err != nil {
+		fmt.Println("✨ Package cache is not enabled")
+		return nil
+	}
+
+	cleanCmd := privilegedCommand(ctx, "apk", "cache", "clean")
+	cleanCmd.Stdout = os.Stdout
+	cleanCmd.Stderr = os.Stderr
+	return cleanCmd.Run()
+}
+
+// Simulate previews an operation with apk's --simulate, which opens the
+// database read-only and so needs no root. Cleaning only touches the
+// cache, so it changes no packages.
+func (a *APK) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	var args []string
+	switch op {
+	case OpInstall:
+		args = append([]string{"add"}, packages...)
+	case OpRemove:
+		args = append([]string{"del"}, packages...)
+	case OpUpdate:
+		args = []string{"upgrade"}
+	case OpClean:
+		return &ChangeSet{}, nil
+	}
+
+	cmd := newCommand(ctx, "apk", append([]string{"--simulate"}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAPKSimulation(string(output)), nil
+}
+
+// parseAPKSimulation parses the steps apk prints:
+//
+//	(1/2) Installing ncurses-terminfo-base (6.4_p20230506-r0)
+//	(2/2) Upgrading musl (1.2.4-r1 -> 1.2.4-r2)
+//	(1/1) Purging vim (9.0.2073-r0)
+func parseAPKSimulation(output string) *ChangeSet {
+	set := &ChangeSet{}
+	actions := map[string]ChangeAction{
+		"Installing":  ActionInstall,
+		"Upgrading":   ActionUpgrade,
+		"Downgrading": ActionDowngrade,
+		"Replacing":   ActionReinstall,
+		"Purging":     ActionRemove,
+	}
+
+	lines := strings.SplitSeq(output, "\n")
+	for line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "(") {
+			continue
+		}
+
+		action, ok := actions[fields[1]]
+		if !ok {
+			continue
+		}
+
+		_, versions, _ := strings.Cut(line, " (")
+		versions = strings.TrimSuffix(strings.TrimSpace(versions), ")")
+		change := Change{Action: action, Name: fields[2], Version: versions}
+		if old, version, found := strings.Cut(versions, " -> "); found {
+			change.OldVersion, change.Version = old, version
+		}
+		set.Changes = append(set.Changes, change)
+	}
+
+	return set
+}
+
+// List lists all installed packages
+func (a *APK) List(ctx context.Context) ([]Package, error) {
+	// apk list --installed output: "musl-1.2.4-r2 x86_64 {musl} (MIT) [installed]"
+	cmd := newCommand(ctx, "apk", "list", "--installed")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// /etc/apk/world holds the packages that were explicitly requested
+	world, _ := os.ReadFile(hostPath("/etc/apk/world"))
+
+	return parseAPKList(string(output), string(world)), nil
+}
+
+// parseAPKList parses "apk list --installed" output, marking packages
+// named in the world file as explicitly installed
+func parseAPKList(output, world string) []Package {
+	explicit := map[string]bool{}
+	for entry := range strings.FieldsSeq(world) {
+		// World entries may carry constraints: "foo>=1.2", "bar@edge"
+		name := strings.FieldsFunc(entry, func(r rune) bool {
+			return strings.ContainsRune("<>=~@", r)
+		})
+		if len(name) > 0 {
+			explicit[name[0]] = true
+		}
+	}
+
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+
+		// "py3-foo-1.2.3-r0" → name "py3-foo", version "1.2.3", release "r0"
+		rest, release := splitVersionRelease(parts[0])
+		name, version := splitVersionRelease(rest)
+
+		pkg := Package{
+			Name:    name,
+			Version: version,
+			Release: release,
+			Arch:    parts[1],
+			Source:  "apk",
+			Reason:  ReasonDependency,
+		}
+		if len(parts) >= 3 {
+			pkg.Repository = strings.Trim(parts[2], "{}") // Origin (source package)
+		}
+		if explicit[name] {
+			pkg.Reason = ReasonExplicit
+		}
+
+		results = append(results, pkg)
+	}
+
+	return results
+}
+
+// Search searches the repositories for term
+func (a *APK) Search(ctx context.Context, term string) ([]Package, error) {
+	// apk search -v <term> output: "name-version-rN - description"
+	cmd := newCommand(ctx, "apk", "search", "-v", term)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search packages: %w", err)
+	}
+
+	return parseAPKSearch(string(output)), nil
+}
+
+// Index lists every package in the repositories; apk search without a
+// term matches them all
+func (a *APK) Index(ctx context.Context) ([]Package, error) {
+	output, err := newCommand(ctx, "apk", "search", "-v").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAPKSearch(string(output)), nil
+}
+
+// Info returns details for a package from the repositories
+func (a *APK) Info(ctx context.Context, name string) (*Package, error) {
+	// apk search -v --exact <package>
+	cmd := newCommand(ctx, "apk", "search", "-v", "--exact", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", name, err)
+	}
+
+	packages := parseAPKSearch(string(output))
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	pkg := packages[0]
+	pkg.Installed = a.IsInstalled(ctx, name)
+	return &pkg, nil
+}
+
+// IsAvailable checks the repositories for an exact package name
+func (a *APK) IsAvailable(ctx context.Context, name string) bool {
+	// apk search --exact <package>
+	cmd := newCommand(ctx, "apk", "search", "--exact", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// IsInstalled checks whether a package is installed
+func (a *APK) IsInstalled(ctx context.Context, name string) bool {
+	// apk info -e <package> (exits 0 only when installed)
+	cmd := newCommand(ctx, "apk", "info", "-e", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// parseAPKSearch parses "apk search -v" output
+func parseAPKSearch(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		pkgver, summary, _ := strings.Cut(line, " - ")
+		pkgver = strings.TrimSpace(pkgver)
+		if pkgver == "" {
+			continue
+		}
+
+		rest, release := splitVersionRelease(pkgver)
+		name, version := splitVersionRelease(rest)
+
+		results = append(results, Package{
+			Name:    name,
+			Summary: strings.TrimSpace(summary),
+			Version: version,
+			Release: release,
+			Source:  "apk",
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,189 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAPKList(t *testing.T) {
+	useFixtures(t, "apk")
+	root := fakeRoot(t)
+	writeFile(t, root, "/etc/apk/world", "alpine-base\nvim>=9\n")
+
+	packages, err := NewAPK().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "alpine-baselayout", Version: "3.6.5", Release: "r0", Arch: "x86_64", Repository: "alpine-baselayout", Source: "apk", Reason: ReasonDependency},
+		{Name: "musl", Version: "1.2.5", Release: "r0", Arch: "x86_64", Repository: "musl", Source: "apk", Reason: ReasonDependency},
+		{Name: "vim", Version: "9.1.0707", Release: "r0", Arch: "x86_64", Repository: "vim", Source: "apk", Reason: ReasonExplicit},
+		{Name: "xxd", Version: "9.1.0707", Release: "r0", Arch: "x86_64", Repository: "vim", Source: "apk", Reason: ReasonDependency},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestParseAPKList(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		world  string
+		want   []Package
+	}{
+		{
+			name:   "world constraints and tags",
+			output: "py3-pip-24.0-r2 noarch {py3-pip} (MIT) [installed]\ngit-2.45.2-r0 x86_64 {git} (GPL-2.0-or-later) [installed]\n",
+			world:  "py3-pip@edge git~2.45\n",
+			want: []Package{
+				{Name: "py3-pip", Version: "24.0", Release: "r2", Arch: "noarch", Repository: "py3-pip", Source: "apk", Reason: ReasonExplicit},
+				{Name: "git", Version: "2.45.2", Release: "r0", Arch: "x86_64", Repository: "git", Source: "apk", Reason: ReasonExplicit},
+			},
+		},
+		{
+			name:   "no world file",
+			output: "musl-1.2.5-r0 x86_64 {musl} (MIT) [installed]\n",
+			want: []Package{
+				{Name: "musl", Version: "1.2.5", Release: "r0", Arch: "x86_64", Repository: "musl", Source: "apk", Reason: ReasonDependency},
+			},
+		},
+		{
+			name:   "empty",
+			output: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAPKList(tt.output, tt.world); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAPKList() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPKSearch(t *testing.T) {
+	useFixtures(t, "apk")
+
+	packages, err := NewAPK().Search(context.Background(), "vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "neovim", Summary: "Vim-fork focused on extensibility and agility", Version: "0.10.1", Release: "r0", Source: "apk"},
+		{Name: "vim", Summary: "Improved vi-style text editor", Version: "9.1.0707", Release: "r0", Source: "apk"},
+		{Name: "vim-doc", Summary: "Improved vi-style text editor (documentation)", Version: "9.1.0707", Release: "r0", Source: "apk"},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("Search() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestAPKInfo(t *testing.T) {
+	useFixtures(t, "apk")
+
+	pkg, err := NewAPK().Info(context.Background(), "vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Package{Name: "vim", Summary: "Improved vi-style text editor", Version: "9.1.0707", Release: "r0", Source: "apk", Installed: true}
+	if !reflect.DeepEqual(pkg, want) {
+		t.Errorf("Info() =\n%+v\nwant\n%+v", pkg, want)
+	}
+
+	if _, err := NewAPK().Info(context.Background(), "vmi"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("Info(%q) = %v, want %v", "vmi", err, ErrPackageNotFound)
+	}
+}
+
+func TestAPKSimulate(t *testing.T) {
+	fake := useFixtures(t, "apk")
+
+	set, err := NewAPK().Simulate(context.Background(), OpInstall, "neovim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ChangeSet{
+		Changes: []Change{
+			{Action: ActionInstall, Name: "libuv", Version: "1.48.0-r0"},
+			{Action: ActionInstall, Name: "luajit", Version: "2.1_p20240314-r0"},
+			{Action: ActionInstall, Name: "neovim", Version: "0.10.1-r0"},
+		},
+	}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("Simulate() =\n%+v\nwant\n%+v", set, want)
+	}
+
+	// --simulate reads the database without root
+	wantCalls := [][]string{{"apk", "--simulate", "add", "neovim"}}
+	if calls := callArgs(fake); !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("Simulate() ran %q, want %q", calls, wantCalls)
+	}
+}
+
+func TestParseAPKSimulation(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *ChangeSet
+	}{
+		{
+			name:   "nothing to do",
+			output: "OK: 67 MiB in 34 packages\n",
+			want:   &ChangeSet{},
+		},
+		{
+			name: "upgrade",
+			output: `fetch https://dl-cdn.alpinelinux.org/alpine/v3.20/main/x86_64/APKINDEX.tar.gz
+Upgrading critical system libraries and apk-tools:
+(1/2) Upgrading musl (1.2.5-r0 -> 1.2.5-r1)
+(2/2) Downgrading curl (8.10.1-r0 -> 8.9.1-r2)
+OK: 67 MiB in 34 packages
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionUpgrade, Name: "musl", Version: "1.2.5-r1", OldVersion: "1.2.5-r0"},
+					{Action: ActionDowngrade, Name: "curl", Version: "8.9.1-r2", OldVersion: "8.10.1-r0"},
+				},
+			},
+		},
+		{
+			name: "remove",
+			output: `(1/2) Purging vim (9.1.0707-r0)
+(2/2) Purging xxd (9.1.0707-r0)
+Executing busybox-1.36.1-r29.trigger
+OK: 58 MiB in 32 packages
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionRemove, Name: "vim", Version: "9.1.0707-r0"},
+					{Action: ActionRemove, Name: "xxd", Version: "9.1.0707-r0"},
+				},
+			},
+		},
+		{
+			name:   "replacing",
+			output: "(1/1) Replacing vim (9.1.0707-r0)\n",
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionReinstall, Name: "vim", Version: "9.1.0707-r0"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAPKSimulation(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAPKSimulation() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
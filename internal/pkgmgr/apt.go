@@ -1,10 +1,13 @@
 package pkgmgr
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
 )
 
 type APT struct{}
@@ -13,37 +16,93 @@ func NewAPT() *APT {
 	return &APT{}
 }
 
-func (a *APT) Install(packages ...string) error {
+func init() {
+	Register(Backend{
+		Name:         "apt",
+		DisplayName:  "APT",
+		Icon:         "📦",
+		Native:       true,
+		Priority:     20,
+		Capabilities: CapSearch | CapOrphans | CapInstallReason,
+		Detect:       func() bool { return commandExists("apt") },
+		New:          func(cfg *config.Config) PackageManager { return NewAPT() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (a *APT) Name() string {
+	return "apt"
+}
+
+func (a *APT) Install(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 
 	// APT command: sudo apt install -y <packages>
 	args := append([]string{"install", "-y"}, packages...)
-	cmd := exec.Command("sudo", append([]string{"apt"}, args...)...)
+	cmd := privilegedCommand(ctx, "apt", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// InstallVersions installs "name=version" targets. Listings drop the
+// epoch, which APT needs, so it is looked up in the candidates first.
+func (a *APT) InstallVersions(ctx context.Context, versions map[string]string) error {
+	full := map[string]string{}
+	for name, version := range versions {
+		full[name] = a.epochVersion(ctx, name, version)
+	}
+
+	// APT command: sudo apt install -y --allow-downgrades <name=version>
+	args := append([]string{"install", "-y", "--allow-downgrades"}, versionTargets(full, "=")...)
+	cmd := privilegedCommand(ctx, "apt", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (a *APT) Remove(packages ...string) error {
+// epochVersion finds the candidate of name matching version once its epoch
+// is dropped, returning version unchanged if none does
+func (a *APT) epochVersion(ctx context.Context, name, version string) string {
+	// apt-cache madison prints "vim | 2:9.1.0016-1ubuntu7 | <archive> Packages"
+	output, err := newCommand(ctx, "apt-cache", "madison", name).Output()
+	if err != nil {
+		return version
+	}
+
+	for line := range strings.SplitSeq(string(output), "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 2 {
+			continue
+		}
+		candidate := strings.TrimSpace(fields[1])
+		if _, bare := splitEpoch(candidate); bare == version {
+			return candidate
+		}
+	}
+	return version
+}
+
+func (a *APT) Remove(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 
 	// APT command: sudo apt remove -y <packages>
 	args := append([]string{"remove", "-y"}, packages...)
-	cmd := exec.Command("sudo", append([]string{"apt"}, args...)...)
+	cmd := privilegedCommand(ctx, "apt", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (a *APT) Update() error {
+func (a *APT) Update(ctx context.Context) error {
 	// APT update needs two commands: update repo lists, then upgrade packages
 
 	// First: sudo apt update
-	updateCmd := exec.Command("sudo", "apt", "update")
+	updateCmd := privilegedCommand(ctx, "apt", "update")
 	updateCmd.Stdout = os.Stdout
 	updateCmd.Stderr = os.Stderr
 	err := updateCmd.Run()
@@ -52,16 +111,16 @@ func (a *APT) Update() error {
 	}
 
 	// Second: sudo apt upgrade -y
-	upgradeCmd := exec.Command("sudo", "apt", "upgrade", "-y")
+	upgradeCmd := privilegedCommand(ctx, "apt", "upgrade", "-y")
 	upgradeCmd.Stdout = os.Stdout
 	upgradeCmd.Stderr = os.Stderr
 	return upgradeCmd.Run()
 }
 
-func (a *APT) Clean() error {
+func (a *APT) Clean(ctx context.Context) error {
 	// Clean package cache
 	fmt.Println("🧹 Cleaning package cache...")
-	cleanCmd := exec.Command("sudo", "apt", "clean")
+	cleanCmd := privilegedCommand(ctx, "apt", "clean")
 	cleanCmd.Stdout = os.Stdout
 	cleanCmd.Stderr = os.Stderr
 	err := cleanCmd.Run()
@@ -71,34 +130,297 @@ func (a *APT) Clean() error {
 
 	// Remove orphaned packages
 	fmt.Println("🗑️  Removing orphaned packages...")
-	autoremoveCmd := exec.Command("sudo", "apt", "autoremove", "-y")
+	autoremoveCmd := privilegedCommand(ctx, "apt", "autoremove", "-y")
 	autoremoveCmd.Stdout = os.Stdout
 	autoremoveCmd.Stderr = os.Stderr
 	return autoremoveCmd.Run()
 }
 
+// Simulate previews an operation with apt-get's simulation mode, which
+// works without root. Updates are previewed against the package lists
+// as they are, without refreshing them first.
+func (a *APT) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	var args []string
+	switch op {
+	case OpInstall:
+		args = append([]string{"-s", "install"}, packages...)
+	case OpRemove:
+		args = append([]string{"-s", "remove"}, packages...)
+	case OpUpdate:
+		args = []string{"-s", "upgrade"}
+	case OpClean:
+		args = []string{"-s", "autoremove"}
+	}
+
+	cmd := newCommand(ctx, "apt-get", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	set := parseAPTSimulation(string(output))
+	a.fillSizes(ctx, set)
+	return set, nil
+}
+
+// parseAPTSimulation parses the actions "apt-get -s" prints:
+//
+//	Inst libc6 [2.35-0ubuntu3.6] (2.35-0ubuntu3.7 Ubuntu:22.04/jammy-updates [amd64])
+//	Inst vim (2:8.2.3995-1ubuntu2.15 Ubuntu:22.04/jammy-updates [amd64])
+//	Remv nano [6.2-1]
+//
+// The bracketed version is the installed one.
+func parseAPTSimulation(output string) *ChangeSet {
+	set := &ChangeSet{}
+	lines := strings.SplitSeq(output, "\n")
+
+	for line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		name, old, version := fields[1], "", ""
+		rest := fields[2:]
+		if len(rest) > 0 && strings.HasPrefix(rest[0], "[") {
+			old = strings.Trim(rest[0], "[]")
+			rest = rest[1:]
+		}
+		if len(rest) > 0 && strings.HasPrefix(rest[0], "(") {
+			version = strings.TrimPrefix(rest[0], "(")
+		}
+
+		switch fields[0] {
+		case "Inst":
+			change := Change{Action: ActionInstall, Name: name, Version: version, OldVersion: old}
+			if old != "" {
+				switch compareVersions(version, old) {
+				case 1:
+					change.Action = ActionUpgrade
+				case -1:
+					change.Action = ActionDowngrade
+				default:
+					change.Action = ActionReinstall
+				}
+			}
+			set.Changes = append(set.Changes, change)
+		case "Remv", "Purg":
+			set.Add(ActionRemove, name, old, 0)
+		}
+	}
+
+	return set
+}
+
+// fillSizes looks up download sizes for incoming packages and installed
+// sizes for removals. It's best effort: sizes it can't find stay 0.
+func (a *APT) fillSizes(ctx context.Context, set *ChangeSet) {
+	incoming, removed := []string{}, []string{}
+	for _, change := range set.Changes {
+		if change.Action == ActionRemove {
+			removed = append(removed, change.Name)
+		} else {
+			incoming = append(incoming, change.Name)
+		}
+	}
+
+	sizes := map[string]int64{}
+
+	// apt-cache show --no-all-versions prints the candidate's record,
+	// with "Size" in bytes
+	if len(incoming) > 0 {
+		cmd := newCommand(ctx, "apt-cache", append([]string{"show", "--no-all-versions"}, incoming...)...)
+		if output, err := cmd.Output(); err == nil {
+			for record := range strings.SplitSeq(string(output), "\n\n") {
+				fields := parseKeyValue(record)
+				size, _ := strconv.ParseInt(fields["Size"], 10, 64)
+				if _, seen := sizes[fields["Package"]]; !seen {
+					sizes[fields["Package"]] = size
+				}
+			}
+		}
+	}
+
+	// dpkg reports Installed-Size in KiB
+	if len(removed) > 0 {
+		args := append([]string{"-W", "-f=${Package}\t${Installed-Size}\n"}, removed...)
+		cmd := newCommand(ctx, "dpkg-query", args...)
+		if output, err := cmd.Output(); err == nil {
+			for line := range strings.SplitSeq(string(output), "\n") {
+				name, size, _ := strings.Cut(line, "\t")
+				kib, _ := strconv.ParseInt(size, 10, 64)
+				sizes[name] = kib * 1024
+			}
+		}
+	}
+
+	set.DownloadSize = 0
+	for i, change := range set.Changes {
+		name, _, _ := strings.Cut(change.Name, ":") // Drop ":i386"
+		set.Changes[i].Size = sizes[name]
+		if change.Action != ActionRemove {
+			set.DownloadSize += sizes[name]
+		}
+	}
+}
+
 // List lists all installed packages
-func (a *APT) List() ([]string, error) {
+func (a *APT) List(ctx context.Context) ([]Package, error) {
 	// apt list --installed
-	cmd := exec.Command("apt", "list", "--installed")
+	cmd := newCommand(ctx, "apt", "list", "--installed")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse output
-	var results []string
-	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+	return parseAPTList(string(output)), nil
+}
+
+// parseAPTList parses "apt list --installed" output:
+//
+//	vim/jammy-updates,now 2:8.2.3995-1ubuntu2.15 amd64 [installed,automatic]
+func parseAPTList(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
 
 	for line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" || line == "Listing..." {
 			continue
 		}
-		// apt list output: "package-name/repo,repo version [installed]"
-		pkgName := strings.Split(line, "/")[0]
-		results = append(results, pkgName)
+
+		parts := strings.Fields(line)
+		if len(parts) < 3 {
+			continue
+		}
+
+		name, suites, _ := strings.Cut(parts[0], "/")
+
+		// "now" only means "installed"; the first real suite is the origin
+		repo := ""
+		for suite := range strings.SplitSeq(suites, ",") {
+			if suite != "now" && suite != "" {
+				repo = suite
+				break
+			}
+		}
+
+		// Drop the epoch, "2:8.2-1" → "8.2-1"
+		version := parts[1]
+		if i := strings.Index(version, ":"); i >= 0 {
+			version = version[i+1:]
+		}
+		version, release := splitVersionRelease(version)
+
+		reason := ReasonExplicit
+		if len(parts) >= 4 && strings.Contains(parts[3], "automatic") {
+			reason = ReasonDependency
+		}
+
+		results = append(results, Package{
+			Name:       name,
+			Version:    version,
+			Release:    release,
+			Arch:       parts[2],
+			Repository: repo,
+			Source:     "apt",
+			Reason:     reason,
+		})
+	}
+
+	return results
+}
+
+// Search searches package names for term
+func (a *APT) Search(ctx context.Context, term string) ([]Package, error) {
+	// apt-cache search --names-only <term> output: "name - summary"
+	cmd := newCommand(ctx, "apt-cache", "search", "--names-only", term)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search packages: %w", err)
+	}
+
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+
+	for line := range lines {
+		name, summary, found := strings.Cut(line, " - ")
+		if !found || name == "" {
+			continue
+		}
+		results = append(results, Package{
+			Name:    strings.TrimSpace(name),
+			Summary: strings.TrimSpace(summary),
+			Source:  "apt",
+		})
 	}
 
 	return results, nil
 }
+
+// Index lists every package in the APT cache; "." matches any name
+func (a *APT) Index(ctx context.Context) ([]Package, error) {
+	return a.Search(ctx, ".")
+}
+
+// Info returns details for a package from the APT cache
+func (a *APT) Info(ctx context.Context, name string) (*Package, error) {
+	// apt-cache show <package> (one record per available version, newest first)
+	cmd := newCommand(ctx, "apt-cache", "show", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	record, _, _ := strings.Cut(string(output), "\n\n")
+	fields := parseKeyValue(record)
+
+	version := fields["Version"]
+	if i := strings.Index(version, ":"); i >= 0 {
+		version = version[i+1:]
+	}
+	version, release := splitVersionRelease(version)
+
+	summary := fields["Description"]
+	if summary == "" {
+		summary = fields["Description-en"]
+	}
+
+	// Installed-Size is given in KiB
+	size, _ := strconv.ParseInt(fields["Installed-Size"], 10, 64)
+
+	return &Package{
+		Name:          fields["Package"],
+		Summary:       summary,
+		URL:           fields["Homepage"],
+		Version:       version,
+		Release:       release,
+		Arch:          fields["Architecture"],
+		Source:        "apt",
+		InstalledSize: size * 1024,
+		Installed:     a.IsInstalled(ctx, name),
+	}, nil
+}
+
+// IsAvailable checks the APT cache for an exact package name
+func (a *APT) IsAvailable(ctx context.Context, name string) bool {
+	// apt-cache search <package>
+	cmd := newCommand(ctx, "apt-cache", "search", "--names-only", "^"+name+"$")
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// IsInstalled checks dpkg for an installed package
+func (a *APT) IsInstalled(ctx context.Context, name string) bool {
+	// dpkg-query -W -f='${Status}' <package> (check installed)
+	cmd := newCommand(ctx, "dpkg-query", "-W", "-f=${Status}", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	// "install ok installed"; removed packages report "deinstall ok config-files"
+	return strings.HasSuffix(strings.TrimSpace(string(output)), " installed")
+}
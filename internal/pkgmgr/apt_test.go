@@ -0,0 +1,131 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAPTList(t *testing.T) {
+	useFixtures(t, "apt")
+
+	packages, err := NewAPT().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "bash", Version: "5.2.21", Release: "2ubuntu4", Arch: "amd64", Repository: "noble", Source: "apt", Reason: ReasonDependency},
+		{Name: "libc6", Version: "2.39", Release: "0ubuntu8.3", Arch: "amd64", Repository: "noble-updates", Source: "apt", Reason: ReasonExplicit},
+		{Name: "vim", Version: "9.1.0016", Release: "1ubuntu7.2", Arch: "amd64", Repository: "noble-updates", Source: "apt", Reason: ReasonExplicit},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestParseAPTList(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Package
+	}{
+		{
+			name:   "header only",
+			output: "Listing...\n",
+			want:   nil,
+		},
+		{
+			name:   "local package",
+			output: "code/now 1.94.2-1728494015 amd64 [installed,local]\n",
+			want: []Package{
+				{Name: "code", Version: "1.94.2", Release: "1728494015", Arch: "amd64", Source: "apt", Reason: ReasonExplicit},
+			},
+		},
+		{
+			name:   "several suites",
+			output: "curl/noble-updates,noble-security,now 8.5.0-2ubuntu10.4 amd64 [installed]\n",
+			want: []Package{
+				{Name: "curl", Version: "8.5.0", Release: "2ubuntu10.4", Arch: "amd64", Repository: "noble-updates", Source: "apt", Reason: ReasonExplicit},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAPTList(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAPTList() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPTSimulate(t *testing.T) {
+	useFixtures(t, "apt")
+
+	set, err := NewAPT().Simulate(context.Background(), OpInstall, "neovim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ChangeSet{
+		Changes: []Change{
+			{Action: ActionInstall, Name: "neovim-runtime", Version: "0.9.5-6ubuntu2", Size: 5848812},
+			{Action: ActionInstall, Name: "neovim", Version: "0.9.5-6ubuntu2", Size: 693298},
+		},
+		DownloadSize: 5848812 + 693298,
+	}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("Simulate() =\n%+v\nwant\n%+v", set, want)
+	}
+}
+
+func TestParseAPTSimulation(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Change
+	}{
+		{
+			name:   "nothing to do",
+			output: "0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.\n",
+			want:   nil,
+		},
+		{
+			name:   "upgrade",
+			output: "Inst libc6 [2.35-0ubuntu3.6] (2.35-0ubuntu3.7 Ubuntu:22.04/jammy-updates [amd64])\n",
+			want:   []Change{{Action: ActionUpgrade, Name: "libc6", Version: "2.35-0ubuntu3.7", OldVersion: "2.35-0ubuntu3.6"}},
+		},
+		{
+			name:   "downgrade",
+			output: "Inst vim [2:9.1.0016-1ubuntu7.2] (2:9.1.0016-1ubuntu7 Ubuntu:24.04/noble [amd64])\n",
+			want:   []Change{{Action: ActionDowngrade, Name: "vim", Version: "2:9.1.0016-1ubuntu7", OldVersion: "2:9.1.0016-1ubuntu7.2"}},
+		},
+		{
+			name:   "reinstall",
+			output: "Inst vim [2:9.1.0016-1ubuntu7.2] (2:9.1.0016-1ubuntu7.2 Ubuntu:24.04/noble-updates [amd64])\n",
+			want:   []Change{{Action: ActionReinstall, Name: "vim", Version: "2:9.1.0016-1ubuntu7.2", OldVersion: "2:9.1.0016-1ubuntu7.2"}},
+		},
+		{
+			name:   "remove and purge",
+			output: "Remv nano [7.2-2build1]\nPurg vim-tiny [2:9.1.0016-1ubuntu7]\n",
+			want: []Change{
+				{Action: ActionRemove, Name: "nano", Version: "7.2-2build1"},
+				{Action: ActionRemove, Name: "vim-tiny", Version: "2:9.1.0016-1ubuntu7"},
+			},
+		},
+		{
+			name:   "configure lines are ignored",
+			output: "Inst htop (3.3.0-4build1 Ubuntu:24.04/noble [amd64])\nConf htop (3.3.0-4build1 Ubuntu:24.04/noble [amd64])\n",
+			want:   []Change{{Action: ActionInstall, Name: "htop", Version: "3.3.0-4build1"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAPTSimulation(tt.output).Changes; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAPTSimulation() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// AUR represents the Arch User Repository, accessed through a helper
+type AUR struct {
+	Helper string // "paru" or "yay"
+}
+
+// NewAUR creates a new AUR instance using the first helper found on PATH
+func NewAUR() *AUR {
+	return &AUR{Helper: detectAURHelper()}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "aur",
+		DisplayName:  "AUR",
+		Icon:         "🏗️ ",
+		Priority:     30,
+		Capabilities: CapSearch | CapInstallReason,
+		Detect:       func() bool { return isAURHelperInstalled() },
+		Enabled:      func(cfg *config.Config) bool { return cfg.AUREnabled && cfg.PackageManager == "pacman" },
+		New:          func(cfg *config.Config) PackageManager { return NewAUR() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (a *AUR) Name() string {
+	return "aur"
+}
+
+// detectAURHelper returns the installed AUR helper, preferring paru over yay
+func detectAURHelper() string {
+	for _, helper := range []string{"paru", "yay"} {
+		if commandExists(helper) {
+			return helper
+		}
+	}
+	return ""
+}
+
+// isAURHelperInstalled checks if paru or yay is available
+func isAURHelperInstalled() bool {
+	return detectAURHelper() != ""
+}
+
+// run executes the helper. Helpers call sudo themselves and refuse to
+// run as root, so they must never be wrapped in sudo.
+func (a *AUR) run(ctx context.Context, args ...string) error {
+	if a.Helper == "" {
+		return fmt.Errorf("no AUR helper found (install paru or yay)")
+	}
+	cmd := newCommand(ctx, a.Helper, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Install builds and installs packages from the AUR
+func (a *AUR) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Helper command: <helper> -S --aur --noconfirm <packages>
+	return a.run(ctx, append([]string{"-S", "--aur", "--noconfirm"}, packages...)...)
+}
+
+// Remove uninstalls AUR packages
+func (a *AUR) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Helper command: <helper> -R --noconfirm <packages>
+	return a.run(ctx, append([]string{"-R", "--noconfirm"}, packages...)...)
+}
+
+// Update upgrades AUR packages only, repo packages are left to pacman
+func (a *AUR) Update(ctx context.Context) error {
+	// Helper command: <helper> -Sua --noconfirm
+	return a.run(ctx, "-Sua", "--noconfirm")
+}
+
+// Clean removes the helper's build cache
+func (a *AUR) Clean(ctx context.Context) error {
+	fmt.Println("🧹 Cleaning AUR build cache...")
+	return a.run(ctx, "-Sc", "--aur", "--noconfirm")
+}
+
+// Simulate previews an operation. Helpers have no dry run, so installs
+// are read from the AUR's metadata (build dependencies aren't listed)
+// and updates from the helper's pending upgrades.
+func (a *AUR) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	set := &ChangeSet{}
+
+	switch op {
+	case OpInstall:
+		for _, pkg := range packages {
+			info, err := a.Info(ctx, pkg)
+			if err != nil {
+				return nil, err
+			}
+			set.Add(ActionInstall, pkg, info.FullVersion(), 0)
+		}
+		if err := markUpgrades(ctx, a, set); err != nil {
+			return nil, err
+		}
+
+	case OpRemove:
+		// Removing is plain pacman
+		return NewPacman().Simulate(ctx, OpRemove, packages...)
+
+	case OpUpdate:
+		if a.Helper == "" {
+			return nil, fmt.Errorf("no AUR helper found (install paru or yay)")
+		}
+
+		// Helper command: <helper> -Qua, exits 1 when nothing is outdated
+		cmd := newCommand(ctx, a.Helper, "-Qua")
+		output, err := cmd.Output()
+		if err != nil && len(output) > 0 {
+			return nil, err
+		}
+		set = parseAURUpdates(string(output))
+
+	case OpClean:
+		// Only the build cache goes, no packages
+	}
+
+	return set, nil
+}
+
+// parseAURUpdates parses "<helper> -Qua" output: "name 1.0-1 -> 1.1-1"
+func parseAURUpdates(output string) *ChangeSet {
+	set := &ChangeSet{}
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 4 || parts[2] != "->" {
+			continue
+		}
+		set.Changes = append(set.Changes, Change{
+			Action:     ActionUpgrade,
+			Name:       parts[0],
+			Version:    parts[3],
+			OldVersion: parts[1],
+		})
+	}
+
+	return set
+}
+
+// List lists all installed foreign (AUR) packages
+func (a *AUR) List(ctx context.Context) ([]Package, error) {
+	// pacman -Qim: detailed info for foreign packages only
+	cmd := newCommand(ctx, "pacman", "-Qim")
+	output, err := cmd.Output()
+	if err != nil {
+		// pacman -Qim exits 1 when there are no foreign packages
+		if len(output) == 0 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	packages := parsePacmanInfo(string(output), "aur")
+	for i := range packages {
+		packages[i].Repository = "aur"
+	}
+
+	return packages, nil
+}
+
+// Search searches the AUR for term
+func (a *AUR) Search(ctx context.Context, term string) ([]Package, error) {
+	if a.Helper == "" {
+		return nil, fmt.Errorf("no AUR helper found (install paru or yay)")
+	}
+
+	// Helper command: <helper> -Ss --aur <term>
+	cmd := newCommand(ctx, a.Helper, "-Ss", "--aur", term)
+	output, err := cmd.Output()
+	if err != nil {
+		if len(output) == 0 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parsePacmanSearch(string(output), "aur"), nil
+}
+
+// Info returns details for an AUR package
+func (a *AUR) Info(ctx context.Context, name string) (*Package, error) {
+	if a.Helper == "" {
+		return nil, fmt.Errorf("no AUR helper found (install paru or yay)")
+	}
+
+	// Helper command: <helper> -Si --aur <package>
+	cmd := newCommand(ctx, a.Helper, "-Si", "--aur", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w in the AUR: %s", ErrPackageNotFound, name)
+	}
+
+	packages := parsePacmanInfo(string(output), "aur")
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("%w in the AUR: %s", ErrPackageNotFound, name)
+	}
+
+	pkg := packages[0]
+	pkg.Repository = "aur"
+	pkg.Installed = a.IsInstalled(ctx, name)
+	return &pkg, nil
+}
+
+// IsAvailable checks the AUR for an exact package name
+func (a *AUR) IsAvailable(ctx context.Context, name string) bool {
+	packages, err := a.Search(ctx, name)
+	if err != nil {
+		return false
+	}
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInstalled checks for an installed foreign package
+func (a *AUR) IsInstalled(ctx context.Context, name string) bool {
+	// pacman -Qm <package>
+	cmd := newCommand(ctx, "pacman", "-Qm", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewAURHelper(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{name: "paru preferred", paths: []string{"paru", "yay"}, want: "paru"},
+		{name: "yay", paths: []string{"yay"}, want: "yay"},
+		{name: "none", paths: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFixtures(t)
+			fake.AddPath(tt.paths...)
+
+			if got := NewAUR().Helper; got != tt.want {
+				t.Errorf("NewAUR().Helper = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAURWithoutHelper(t *testing.T) {
+	fake := useFixtures(t)
+
+	if _, err := (&AUR{}).Search(context.Background(), "yay"); err == nil {
+		t.Error("Search() succeeded without an AUR helper")
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("Search() ran %q without an AUR helper", callArgs(fake))
+	}
+}
+
+func TestAURList(t *testing.T) {
+	useFixtures(t, "aur")
+
+	packages, err := NewAUR().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "yay", Summary: "Yet another yogurt. Pacman wrapper and AUR helper written in go.", URL: "https://github.com/Jguer/yay", Version: "12.4.2", Release: "1", Arch: "x86_64", Repository: "aur", Source: "aur", InstalledSize: 9_216_983, Reason: ReasonExplicit},
+		{Name: "ttf-ms-fonts", Summary: "Core TrueType Fonts from Microsoft", URL: "http://corefonts.sourceforge.net/", Version: "2.0", Release: "12", Arch: "any", Repository: "aur", Source: "aur", InstalledSize: 4_246_732, Reason: ReasonDependency},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestAURListWithoutForeignPackages(t *testing.T) {
+	fake := useFixtures(t)
+	fake.Add(Fixture{Args: []string{"pacman", "-Qim"}, ExitCode: 1})
+
+	packages, err := NewAUR().List(context.Background())
+	if err != nil || packages != nil {
+		t.Errorf("List() = %+v, %v, want no packages and no error", packages, err)
+	}
+}
+
+func TestAURSearch(t *testing.T) {
+	useFixtures(t, "aur")
+
+	tests := []struct {
+		term string
+		want []Package
+	}{
+		{
+			term: "yay",
+			want: []Package{
+				{Name: "yay", Summary: "Yet another yogurt. Pacman wrapper and AUR helper written in go.", Version: "12.4.2", Release: "1", Repository: "aur", Source: "aur", Installed: true},
+				{Name: "yay-bin", Summary: "Yet another yogurt. Pacman wrapper and AUR helper written in go. Pre-compiled.", Version: "12.4.2", Release: "1", Repository: "aur", Source: "aur"},
+			},
+		},
+		{
+			term: "doesnotexist",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			got, err := NewAUR().Search(context.Background(), tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) =\n%+v\nwant\n%+v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAURInfo(t *testing.T) {
+	useFixtures(t, "aur")
+
+	pkg, err := NewAUR().Info(context.Background(), "yay")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Package{
+		Name:       "yay",
+		Summary:    "Yet another yogurt. Pacman wrapper and AUR helper written in go.",
+		URL:        "https://github.com/Jguer/yay",
+		Version:    "12.4.2",
+		Release:    "1",
+		Repository: "aur",
+		Source:     "aur",
+		Installed:  true,
+	}
+	if !reflect.DeepEqual(pkg, want) {
+		t.Errorf("Info() =\n%+v\nwant\n%+v", pkg, want)
+	}
+
+	if _, err := NewAUR().Info(context.Background(), "yya"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("Info(%q) = %v, want %v", "yya", err, ErrPackageNotFound)
+	}
+}
+
+func TestParseAURUpdates(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *ChangeSet
+	}{
+		{
+			name:   "up to date",
+			output: "",
+			want:   &ChangeSet{},
+		},
+		{
+			name: "updates",
+			output: `:: Searching AUR for updates...
+yay 12.4.1-1 -> 12.4.2-1
+ttf-ms-fonts 2.0-11 -> 2.0-12
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionUpgrade, Name: "yay", Version: "12.4.2-1", OldVersion: "12.4.1-1"},
+					{Action: ActionUpgrade, Name: "ttf-ms-fonts", Version: "2.0-12", OldVersion: "2.0-11"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAURUpdates(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAURUpdates() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Operation names something a PackageManager can be asked to do
+type Operation string
+
+const (
+	OpInstall Operation = "install"
+	OpRemove  Operation = "remove"
+	OpUpdate  Operation = "update"
+	OpClean   Operation = "clean"
+)
+
+// ChangeAction says what a transaction would do to one package
+type ChangeAction string
+
+const (
+	ActionInstall   ChangeAction = "install"
+	ActionUpgrade   ChangeAction = "upgrade"
+	ActionDowngrade ChangeAction = "downgrade"
+	ActionReinstall ChangeAction = "reinstall"
+	ActionRemove    ChangeAction = "remove"
+)
+
+// Change is one package a transaction would touch
+type Change struct {
+	Action     ChangeAction `json:"action"`
+	Name       string       `json:"name"`
+	Version    string       `json:"version,omitempty"`     // Version after the change, the removed one for removals
+	OldVersion string       `json:"old_version,omitempty"` // Installed version replaced by an upgrade or downgrade
+	Size       int64        `json:"size,omitempty"`        // Download size, or the space freed by a removal; 0 when unknown
+}
+
+// ChangeSet is what an operation would change in one source
+type ChangeSet struct {
+	Source       string   `json:"source"`
+	Changes      []Change `json:"changes"`
+	DownloadSize int64    `json:"download_size,omitempty"` // Total to download, 0 when unknown
+}
+
+// Add appends a change
+func (s *ChangeSet) Add(action ChangeAction, name, version string, size int64) {
+	s.Changes = append(s.Changes, Change{Action: action, Name: name, Version: version, Size: size})
+}
+
+// Filter returns the changes with the given action
+func (s *ChangeSet) Filter(action ChangeAction) []Change {
+	changes := []Change{}
+	for _, change := range s.Changes {
+		if change.Action == action {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Simulator is implemented by backends that can work out what an
+// operation would change without changing anything
+type Simulator interface {
+	Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error)
+}
+
+// ErrSimulationUnsupported is returned for backends that can't preview
+// an operation
+var ErrSimulationUnsupported = errors.New("can't preview changes")
+
+// Simulate previews op on pm. Upgrades the backend couldn't give an old
+// version for are filled in from the installed packages.
+func Simulate(ctx context.Context, pm PackageManager, op Operation, packages ...string) (*ChangeSet, error) {
+	simulator, ok := pm.(Simulator)
+	if !ok {
+		return nil, fmt.Errorf("%s %w", DisplayName(pm), ErrSimulationUnsupported)
+	}
+
+	set, err := simulator.Simulate(ctx, op, packages...)
+	if err != nil {
+		return nil, err
+	}
+	set.Source = pm.Name()
+
+	if err := fillOldVersions(ctx, pm, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// fillOldVersions looks up the installed version of upgraded and
+// downgraded packages that lack one
+func fillOldVersions(ctx context.Context, pm PackageManager, set *ChangeSet) error {
+	missing := false
+	for _, change := range set.Changes {
+		if isReplacement(change.Action) && change.OldVersion == "" {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return nil
+	}
+
+	installed, err := InstalledVersions(ctx, pm)
+	if err != nil {
+		return err
+	}
+	for i, change := range set.Changes {
+		if isReplacement(change.Action) && change.OldVersion == "" {
+			set.Changes[i].OldVersion = installed[change.Name]
+		}
+	}
+	return nil
+}
+
+// markUpgrades turns installs of packages that are already installed into
+// upgrades, downgrades or reinstalls, for tools that list them together
+func markUpgrades(ctx context.Context, pm PackageManager, set *ChangeSet) error {
+	installed, err := InstalledVersions(ctx, pm)
+	if err != nil {
+		return err
+	}
+
+	for i, change := range set.Changes {
+		old, found := installed[change.Name]
+		if change.Action != ActionInstall || !found {
+			continue
+		}
+
+		// Listings drop the epoch, so only compare it when both have one
+		version := change.Version
+		if !strings.Contains(old, ":") {
+			_, version = splitEpoch(version)
+		}
+
+		set.Changes[i].OldVersion = old
+		switch compareVersions(version, old) {
+		case 1:
+			set.Changes[i].Action = ActionUpgrade
+		case -1:
+			set.Changes[i].Action = ActionDowngrade
+		default:
+			set.Changes[i].Action = ActionReinstall
+		}
+	}
+	return nil
+}
+
+// InstalledVersions maps installed package names to their full version
+func InstalledVersions(ctx context.Context, pm PackageManager) (map[string]string, error) {
+	packages, err := pm.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed packages: %w", err)
+	}
+
+	versions := map[string]string{}
+	for _, pkg := range packages {
+		versions[pkg.Name] = pkg.FullVersion()
+	}
+	return versions, nil
+}
+
+func isReplacement(action ChangeAction) bool {
+	return action == ActionUpgrade || action == ActionDowngrade
+}
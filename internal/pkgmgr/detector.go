@@ -2,42 +2,31 @@ package pkgmgr
 
 import (
 	"fmt"
-	"os/exec"
+	"strings"
 )
 
-// DetectPackageManager automatically detects which package manager to use
+// DetectPackageManager automatically detects which package manager to use,
+// probing the registered native backends in priority order
 func DetectPackageManager() (PackageManager, error) {
-	// Check for DNF (Fedora, RHEL, CentOS)
-	if _, err := exec.LookPath("dnf"); err == nil {
-		return NewDNF(), nil
+	for _, backend := range Backends() {
+		if backend.Native && backend.Detect() {
+			return backend.New(nil), nil
+		}
 	}
 
-	// Check for APT (Ubuntu, Debian)
-	if _, err := exec.LookPath("apt"); err == nil {
-		return NewAPT(), nil
-	}
-
-	// Check for Pacman (Arch, Manjaro)
-	if _, err := exec.LookPath("pacman"); err == nil {
-		return NewPacman(), nil
-	}
-
-	return nil, fmt.Errorf("no supported package manager found (dnf, apt, pacman)")
+	return nil, fmt.Errorf("no supported package manager found (%s)", strings.Join(NativeBackendNames(), ", "))
 }
 
 func isFlatpakInstalled() bool {
-	cmd := exec.Command("which", "flatpak")
-	return cmd.Run() == nil
+	return commandExists("flatpak")
 }
 
 // Check if snap is installed
 func isSnapInstalled() bool {
-	cmd := exec.Command("which", "snap")
-	return cmd.Run() == nil
+	return commandExists("snap")
 }
 
 // Check if rpm is available
 func isRPMInstalled() bool {
-	cmd := exec.Command("which", "rpm")
-	return cmd.Run() == nil
+	return commandExists("rpm")
 }
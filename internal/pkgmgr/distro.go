@@ -7,26 +7,31 @@ import (
 )
 
 func detectDistribution() string {
-	data, err := os.ReadFile("/etc/os-release")
+	data, err := os.ReadFile(hostPath("/etc/os-release"))
 	if err == nil {
 		return parseOSRelease(string(data))
 	}
 
-	data, err = os.ReadFile("/etc/lsb-release")
+	data, err = os.ReadFile(hostPath("/etc/lsb-release"))
 	if err == nil {
 		return parseLSBRelease(string(data))
 	}
 
-	data, err = os.ReadFile("/etc/fedora-release")
+	data, err = os.ReadFile(hostPath("/etc/fedora-release"))
 	if err == nil {
 		return string(data)
 	}
 
-	_, err = os.ReadFile("/etc/arch-release")
+	_, err = os.ReadFile(hostPath("/etc/arch-release"))
 	if err == nil {
 		return "Arch Linux"
 	}
 
+	_, err = os.Stat(hostPath("/var/db/xbps"))
+	if err == nil {
+		return "Void Linux"
+	}
+
 	return "Unknown Linux Distribution"
 }
 
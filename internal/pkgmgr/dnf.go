@@ -1,10 +1,14 @@
 package pkgmgr
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
 )
 
 type DNF struct{}
@@ -13,80 +17,387 @@ func NewDNF() *DNF {
 	return &DNF{}
 }
 
-func (d *DNF) Install(packages ...string) error {
+func init() {
+	Register(Backend{
+		Name:         "dnf",
+		DisplayName:  "DNF",
+		Icon:         "📦",
+		Native:       true,
+		Priority:     10,
+		Capabilities: CapSearch | CapOrphans,
+		Detect:       func() bool { return commandExists("dnf") },
+		New:          func(cfg *config.Config) PackageManager { return NewDNF() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (d *DNF) Name() string {
+	return "dnf"
+}
+
+func (d *DNF) Install(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 	args := append([]string{"install", "-y"}, packages...)
-	cmd := exec.Command("sudo", append([]string{"dnf"}, args...)...)
+	cmd := privilegedCommand(ctx, "dnf", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (d *DNF) Remove(packages ...string) error {
+// InstallVersions installs "name-version" specs, which DNF also uses to
+// downgrade a package that is installed at a newer version
+func (d *DNF) InstallVersions(ctx context.Context, versions map[string]string) error {
+	args := append([]string{"install", "-y"}, versionTargets(versions, "-")...)
+	cmd := privilegedCommand(ctx, "dnf", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (d *DNF) Remove(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 	args := append([]string{"remove", "-y"}, packages...)
-	cmd := exec.Command("sudo", append([]string{"dnf"}, args...)...)
+	cmd := privilegedCommand(ctx, "dnf", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (d *DNF) Update() error {
+func (d *DNF) Update(ctx context.Context) error {
 	// DNF command: sudo dnf update -y
-	cmd := exec.Command("sudo", "dnf", "update", "-y")
+	cmd := privilegedCommand(ctx, "dnf", "update", "-y")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (d *DNF) Clean() error {
+func (d *DNF) Clean(ctx context.Context) error {
 	// Remove orphaned packages
 	fmt.Println("🗑️  Removing orphaned packages...")
-	autoremoveCmd := exec.Command("sudo", "dnf", "autoremove", "-y")
+	autoremoveCmd := privilegedCommand(ctx, "dnf", "autoremove", "-y")
 	autoremoveCmd.Stdout = os.Stdout
 	autoremoveCmd.Stderr = os.Stderr
 	return autoremoveCmd.Run()
 }
 
+// Simulate previews an operation by answering no to dnf's prompt
+func (d *DNF) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	var args []string
+	switch op {
+	case OpInstall:
+		args = append([]string{"install", "--assumeno"}, packages...)
+	case OpRemove:
+		args = append([]string{"remove", "--assumeno"}, packages...)
+	case OpUpdate:
+		args = []string{"upgrade", "--assumeno"}
+	case OpClean:
+		args = []string{"autoremove", "--assumeno"}
+	}
+
+	// dnf only resolves transactions as root, and exits 1 after the "no"
+	var output bytes.Buffer
+	cmd := privilegedDryRun(ctx, "dnf", args...)
+	cmd.Stdout = &output
+	err := cmd.Run()
+
+	set := parseDNFTransaction(output.String())
+	if err != nil && len(set.Changes) == 0 {
+		return nil, err
+	}
+	return set, nil
+}
+
+// parseDNFTransaction parses the transaction table dnf prints before
+// asking for confirmation:
+//
+//	Installing:
+//	 vim-enhanced     x86_64   2:9.1.158-1.fc40   updates   2.0 M
+//	Upgrading:
+//	 curl             x86_64   8.6.0-10.fc40      updates   1.2 MiB
+//	   replacing curl x86_64   8.6.0-8.fc40       @System   1.1 MiB
+//
+// dnf5 adds the "replacing" lines; dnf4 wraps long names onto a line of
+// their own.
+func parseDNFTransaction(output string) *ChangeSet {
+	set := &ChangeSet{}
+	sections := []struct {
+		prefix string
+		action ChangeAction
+	}{
+		{"Installing", ActionInstall},
+		{"Upgrading", ActionUpgrade},
+		{"Downgrading", ActionDowngrade},
+		{"Reinstalling", ActionReinstall},
+		{"Removing", ActionRemove},
+	}
+
+	var action ChangeAction
+	wrapped := ""
+	lines := strings.SplitSeq(output, "\n")
+
+	for line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "Transaction Summary"):
+			action = ""
+			continue
+		case strings.HasPrefix(trimmed, "Total download size:"):
+			set.DownloadSize = parseHumanSize(strings.TrimPrefix(trimmed, "Total download size:"))
+			continue
+		case strings.Contains(trimmed, "Need to download "):
+			// dnf5: "Total size of inbound packages is 5 MiB. Need to download 5 MiB."
+			_, size, _ := strings.Cut(trimmed, "Need to download ")
+			set.DownloadSize = parseHumanSize(strings.TrimSuffix(size, "."))
+			continue
+		}
+
+		// Section headers are the only unindented lines ending in ":"
+		if !strings.HasPrefix(line, " ") {
+			if strings.HasSuffix(trimmed, ":") {
+				action = ""
+				for _, section := range sections {
+					if strings.HasPrefix(trimmed, section.prefix) {
+						action = section.action
+					}
+				}
+			}
+			continue
+		}
+		if action == "" {
+			continue
+		}
+
+		fields := strings.Fields(trimmed)
+		if len(fields) == 1 {
+			wrapped = fields[0]
+			continue
+		}
+		if wrapped != "" {
+			fields = append([]string{wrapped}, fields...)
+			wrapped = ""
+		}
+		if len(fields) < 4 {
+			continue
+		}
+
+		if fields[0] == "replacing" {
+			if n := len(set.Changes); n > 0 && len(fields) >= 5 {
+				set.Changes[n-1].OldVersion = fields[3]
+			}
+			continue
+		}
+
+		// name arch version repository [size unit]
+		size := int64(0)
+		if len(fields) >= 5 {
+			size = parseHumanSize(strings.Join(fields[4:], " "))
+		}
+		set.Add(action, fields[0], fields[2], size)
+	}
+
+	return set
+}
+
 // List lists all installed packages
-func (d *DNF) List() ([]string, error) {
+func (d *DNF) List(ctx context.Context) ([]Package, error) {
 	// Try rpm -qa first (more reliable)
-	cmd := exec.Command("rpm", "-qa", "--queryformat", "%{NAME}\n")
-	output, err := cmd.Output()
+	packages, err := queryRPMPackages(ctx, "dnf")
+	if err == nil && len(packages) > 0 {
+		return packages, nil
+	}
 
 	// If rpm fails, try dnf list --installed
-	if err != nil || len(output) == 0 {
-		cmd = exec.Command("dnf", "list", "--installed")
-		output, err = cmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("failed to list packages: %v", err)
-		}
+	cmd := newCommand(ctx, "dnf", "list", "--installed")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
 	}
 
-	// Parse output - each line is a package name
-	var results []string
-	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+	return parseDNFList(string(output)), nil
+}
+
+// rpmQueryFormat makes rpm print one tab separated package per line
+const rpmQueryFormat = "%{NAME}\t%{VERSION}\t%{RELEASE}\t%{ARCH}\t%{SIZE}\n"
+
+// queryRPMPackages lists installed packages straight from the rpm database
+func queryRPMPackages(ctx context.Context, source string) ([]Package, error) {
+	cmd := newCommand(ctx, "rpm", "-qa", "--queryformat", rpmQueryFormat)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRPMQuery(string(output), source), nil
+}
+
+// parseRPMQuery parses output produced with rpmQueryFormat
+func parseRPMQuery(output, source string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
 
 	for line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.Contains(line, "Installed Packages") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 5 || parts[0] == "" {
 			continue
 		}
 
-		// Extract package name (before the space/architecture)
+		size, _ := strconv.ParseInt(parts[4], 10, 64)
+		arch := parts[3]
+		if arch == "(none)" {
+			arch = ""
+		}
+
+		results = append(results, Package{
+			Name:          parts[0],
+			Version:       parts[1],
+			Release:       parts[2],
+			Arch:          arch,
+			Source:        source,
+			InstalledSize: size,
+		})
+	}
+
+	return results
+}
+
+// parseDNFList parses "dnf list --installed" output:
+//
+//	python3.11.x86_64    3.11.9-1.fc40    @updates
+func parseDNFList(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
 		parts := strings.Fields(line)
-		if len(parts) >= 1 {
-			// Split by dot to remove architecture
-			pkgName := strings.Split(parts[0], ".")[0]
-			if pkgName != "" {
-				results = append(results, pkgName)
-			}
+		if len(parts) < 3 || strings.Contains(line, "Installed Packages") {
+			continue
 		}
+
+		// The architecture follows the LAST dot, names may contain dots
+		name, arch := parts[0], ""
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name, arch = name[:i], name[i+1:]
+		}
+
+		// Drop the epoch, "2:1.0-1" → "1.0-1"
+		evr := parts[1]
+		if i := strings.Index(evr, ":"); i >= 0 {
+			evr = evr[i+1:]
+		}
+		version, release := splitVersionRelease(evr)
+
+		results = append(results, Package{
+			Name:       name,
+			Version:    version,
+			Release:    release,
+			Arch:       arch,
+			Repository: strings.TrimPrefix(parts[2], "@"),
+			Source:     "dnf",
+		})
+	}
+
+	return results
+}
+
+// dnfQueryFormat makes dnf repoquery print one tab separated package per line
+const dnfQueryFormat = "%{name}\t%{version}\t%{release}\t%{arch}\t%{repoid}\t%{summary}\t%{url}\n"
+
+// Search searches the repositories for package names containing term
+func (d *DNF) Search(ctx context.Context, term string) ([]Package, error) {
+	// dnf repoquery --queryformat ... *<term>*
+	cmd := newCommand(ctx, "dnf", "repoquery", "--quiet", "--latest-limit=1",
+		"--queryformat", dnfQueryFormat, "*"+term+"*")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search packages: %w", err)
+	}
+
+	return parseDNFQuery(string(output)), nil
+}
+
+// Index lists the latest version of every package in the repositories
+func (d *DNF) Index(ctx context.Context) ([]Package, error) {
+	cmd := newCommand(ctx, "dnf", "repoquery", "--quiet", "--latest-limit=1", "--queryformat", dnfQueryFormat)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDNFQuery(string(output)), nil
+}
+
+// Info returns details for a package from the repositories
+func (d *DNF) Info(ctx context.Context, name string) (*Package, error) {
+	cmd := newCommand(ctx, "dnf", "repoquery", "--quiet", "--latest-limit=1",
+		"--queryformat", dnfQueryFormat, name)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", name, err)
+	}
+
+	packages := parseDNFQuery(string(output))
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	pkg := packages[0]
+	pkg.Installed = d.IsInstalled(ctx, name)
+	return &pkg, nil
+}
+
+// IsAvailable checks the repositories for an exact package name
+func (d *DNF) IsAvailable(ctx context.Context, name string) bool {
+	// dnf repoquery <package> (quiet check)
+	cmd := newCommand(ctx, "dnf", "repoquery", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// IsInstalled checks the rpm database for an exact package name
+func (d *DNF) IsInstalled(ctx context.Context, name string) bool {
+	return isRPMPackageInstalled(ctx, name)
+}
+
+// isRPMPackageInstalled checks the rpm database with rpm -q
+func isRPMPackageInstalled(ctx context.Context, name string) bool {
+	cmd := newCommand(ctx, "rpm", "-q", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// parseDNFQuery parses repoquery output produced with dnfQueryFormat
+func parseDNFQuery(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 7 || parts[0] == "" {
+			continue
+		}
+
+		results = append(results, Package{
+			Name:       parts[0],
+			Version:    parts[1],
+			Release:    parts[2],
+			Arch:       parts[3],
+			Repository: parts[4],
+			Summary:    parts[5],
+			URL:        parts[6],
+			Source:     "dnf",
+		})
 	}
 
-	return results, nil
+	return results
 }
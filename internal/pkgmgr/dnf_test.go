@@ -0,0 +1,185 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDNFList(t *testing.T) {
+	fake := useFixtures(t, "dnf")
+
+	packages, err := NewDNF().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "bash", Version: "5.2.26", Release: "3.fc40", Arch: "x86_64", Repository: "anaconda", Source: "dnf"},
+		{Name: "python3-libdnf5", Version: "5.1.17", Release: "2.fc40", Arch: "x86_64", Repository: "updates", Source: "dnf"},
+		{Name: "python3.11", Version: "3.11.9", Release: "1.fc40", Arch: "x86_64", Repository: "updates", Source: "dnf"},
+		{Name: "vim-enhanced", Version: "9.1.393", Release: "1.fc40", Arch: "x86_64", Repository: "updates", Source: "dnf"},
+		{Name: "xorg-x11-fonts-misc", Version: "7.5", Release: "38.fc40", Arch: "noarch", Repository: "fedora", Source: "dnf"},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+
+	// rpm failing must fall back to dnf list
+	calls := callArgs(fake)
+	if len(calls) != 2 || calls[0][0] != "rpm" || calls[1][0] != "dnf" {
+		t.Errorf("List() ran %q, want rpm then dnf", calls)
+	}
+}
+
+func TestParseDNFList(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Package
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   nil,
+		},
+		{
+			name:   "header only",
+			output: "Installed Packages\n",
+			want:   nil,
+		},
+		{
+			name:   "dots in the name",
+			output: "python3.11.x86_64    3.11.9-1.fc40    @updates\n",
+			want: []Package{
+				{Name: "python3.11", Version: "3.11.9", Release: "1.fc40", Arch: "x86_64", Repository: "updates", Source: "dnf"},
+			},
+		},
+		{
+			name:   "epoch is dropped",
+			output: "vim-enhanced.x86_64    2:9.1.393-1.fc40    @updates\n",
+			want: []Package{
+				{Name: "vim-enhanced", Version: "9.1.393", Release: "1.fc40", Arch: "x86_64", Repository: "updates", Source: "dnf"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDNFList(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDNFList() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNFSimulate(t *testing.T) {
+	useFixtures(t, "dnf")
+
+	set, err := NewDNF().Simulate(context.Background(), OpUpdate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ChangeSet{
+		Changes: []Change{
+			{Action: ActionUpgrade, Name: "vim-enhanced", Version: "2:9.1.544-1.fc40", Size: 2 << 20},
+			{Action: ActionInstall, Name: "gpm-libs", Version: "1.20.7-46.fc40", Size: 20 << 10},
+		},
+		DownloadSize: 2 << 20,
+	}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("Simulate() =\n%+v\nwant\n%+v", set, want)
+	}
+}
+
+func TestDNFSimulateWhileLocked(t *testing.T) {
+	useFixtures(t, "dnf")
+	holdLock(t, "dnf")
+
+	if _, err := NewDNF().Simulate(context.Background(), OpUpdate); err != nil {
+		t.Errorf("Simulate() = %v while dnf holds its lock, want the dry run to go ahead", err)
+	}
+}
+
+func TestParseDNFTransaction(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *ChangeSet
+	}{
+		{
+			name:   "nothing to do",
+			output: "Dependencies resolved.\nNothing to do.\nComplete!\n",
+			want:   &ChangeSet{},
+		},
+		{
+			name: "dnf4 install and remove",
+			output: `Dependencies resolved.
+================================================================================
+ Package            Arch      Version              Repository            Size
+================================================================================
+Installing:
+ htop               x86_64    3.3.0-3.fc40         fedora               190 k
+Removing:
+ nano               x86_64    7.2-6.fc40           @fedora              3.0 M
+
+Transaction Summary
+================================================================================
+Install  1 Package
+Remove   1 Package
+
+Total download size: 190 k
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionInstall, Name: "htop", Version: "3.3.0-3.fc40", Size: 190 << 10},
+					{Action: ActionRemove, Name: "nano", Version: "7.2-6.fc40", Size: 3 << 20},
+				},
+				DownloadSize: 190 << 10,
+			},
+		},
+		{
+			name: "dnf4 wrapped name",
+			output: `Installing:
+ texlive-collection-fontsrecommended
+                    noarch    11:svn54074-70.fc40  fedora               4.0 k
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionInstall, Name: "texlive-collection-fontsrecommended", Version: "11:svn54074-70.fc40", Size: 4 << 10},
+				},
+			},
+		},
+		{
+			name: "dnf5 replacing",
+			output: `Package              Arch   Version         Repository   Size
+Upgrading:
+ curl                x86_64 8.6.0-10.fc40   updates   1.5 MiB
+   replacing curl    x86_64 8.6.0-8.fc40    @System   1.1 MiB
+Downgrading:
+ less                x86_64 643-4.fc40      fedora  368.0 KiB
+   replacing less    x86_64 661-1.fc40      @System 370.0 KiB
+
+Transaction Summary:
+ Upgrading:          1 package
+Total size of inbound packages is 2 MiB. Need to download 2 MiB.
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionUpgrade, Name: "curl", Version: "8.6.0-10.fc40", OldVersion: "8.6.0-8.fc40", Size: 3 << 19},
+					{Action: ActionDowngrade, Name: "less", Version: "643-4.fc40", OldVersion: "661-1.fc40", Size: 368 << 10},
+				},
+				DownloadSize: 2 << 20,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDNFTransaction(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDNFTransaction() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package pkgmgr
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Failure classes recognised in package manager output. Check for them
+// with errors.Is; the *BackendError carrying them has the details.
+var (
+	ErrPackageNotFound    = errors.New("package not found")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrDatabaseLocked     = errors.New("package database is locked")
+	ErrNetworkUnavailable = errors.New("network unavailable")
+	ErrDependencyConflict = errors.New("dependency conflict")
+	ErrDiskFull           = errors.New("not enough disk space")
+	ErrAborted            = errors.New("aborted by user")
+)
+
+// BackendError is a failed package manager command, classified from its
+// exit code and error output
+type BackendError struct {
+	Manager string     // Program that failed, e.g. "dnf" or "sudo"
+	Kind    error      // One of the Err* classes, nil when unrecognised
+	Detail  string     // The line of output that identified the failure
+	Exit    *ExitError // The raw failure
+}
+
+func (e *BackendError) Error() string {
+	if e.Kind == nil {
+		if e.Detail == "" {
+			return fmt.Sprintf("%s failed: %v", e.Manager, e.Exit)
+		}
+		return fmt.Sprintf("%s failed: %s", e.Manager, e.Detail)
+	}
+	if e.Detail == "" {
+		return fmt.Sprintf("%s: %v", e.Manager, e.Kind)
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Detail)
+}
+
+// Unwrap lets errors.Is match both the class and the exit error
+func (e *BackendError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Exit}
+	}
+	return []error{e.Kind, e.Exit}
+}
+
+// errorPattern maps lowercase fragments of error output to a class
+type errorPattern struct {
+	kind      error
+	fragments []string
+}
+
+// backendErrorPatterns are checked against the failing program's output
+// before commonErrorPatterns. Order matters: "nothing provides foo" is a
+// conflict, not a missing package.
+var backendErrorPatterns = map[string][]errorPattern{
+	"dnf": {
+		{ErrDatabaseLocked, []string{"waiting for process with pid", "failed to obtain the transaction lock", "lock is held"}},
+		{ErrNetworkUnavailable, []string{"failed to download metadata", "cannot download repomd.xml", "curl error", "librepo"}},
+		{ErrDependencyConflict, []string{"problem: ", "conflicting requests", "nothing provides", "conflicts with file from package"}},
+		{ErrPackageNotFound, []string{"no match for argument", "unable to find a match", "no packages marked for removal"}},
+		{ErrAborted, []string{"operation aborted"}},
+	},
+	"apt": {
+		{ErrPermissionDenied, []string{"are you root?"}}, // Also mentions the lock
+		{ErrDatabaseLocked, []string{"could not get lock", "unable to acquire the dpkg frontend lock", "unable to lock the administration directory"}},
+		{ErrNetworkUnavailable, []string{"temporary failure resolving", "failed to fetch", "could not connect to", "some index files failed to download"}},
+		{ErrDependencyConflict, []string{"unmet dependencies", "held broken packages", "unable to correct problems"}},
+		{ErrDiskFull, []string{"you don't have enough free space"}},
+		{ErrPackageNotFound, []string{"unable to locate package", "has no installation candidate"}},
+		{ErrAborted, []string{"abort."}},
+	},
+	"pacman": {
+		{ErrDatabaseLocked, []string{"unable to lock database"}},
+		{ErrPermissionDenied, []string{"you cannot perform this operation unless you are root"}},
+		{ErrNetworkUnavailable, []string{"failed retrieving file", "failed to synchronize", "could not resolve host"}},
+		{ErrDependencyConflict, []string{"unresolvable package conflicts", "could not satisfy dependencies", "are in conflict", "breaks dependency"}},
+		{ErrDiskFull, []string{"not enough free disk space"}},
+		{ErrPackageNotFound, []string{"target not found"}},
+		{ErrAborted, []string{"operation cancelled"}},
+	},
+	"zypper": {
+		{ErrDatabaseLocked, []string{"system management is locked"}},
+		{ErrNetworkUnavailable, []string{"download (curl) error", "valid metadata not found at specified url"}},
+		{ErrDependencyConflict, []string{"problem: ", "nothing provides"}},
+		{ErrPackageNotFound, []string{"no provider of", "not found in package names"}},
+	},
+	"flatpak": {
+		{ErrPermissionDenied, []string{"not allowed for user", "authentication failed", "not authorized"}},
+		{ErrNetworkUnavailable, []string{"while fetching", "can't fetch summary", "unable to connect", "could not resolve hostname"}},
+		{ErrDependencyConflict, []string{"requires the runtime", "needs a later flatpak version"}},
+		{ErrPackageNotFound, []string{"nothing matches", "no remote refs found", "not installed", "no such ref"}},
+	},
+}
+
+// escalationErrorPatterns recognise the escalation tool itself refusing
+var escalationErrorPatterns = []errorPattern{
+	{ErrPermissionDenied, []string{
+		"incorrect password", "a password is required", "is not in the sudoers file", "not allowed to execute",
+		"authentication failed", "not authorized", "interactive authentication required", "access denied",
+	}},
+}
+
+// commonErrorPatterns apply to every program
+var commonErrorPatterns = []errorPattern{
+	{ErrDiskFull, []string{"no space left on device", "disk quota exceeded"}},
+	{ErrPermissionDenied, []string{"permission denied", "operation not permitted"}},
+	{ErrNetworkUnavailable, []string{"network is unreachable", "could not resolve host", "connection timed out"}},
+	{ErrDatabaseLocked, []string{"database is locked"}},
+}
+
+// classifyError turns a failed command into a *BackendError. program is
+// the package manager that ran, tool the escalation tool in front of it
+// (or ""). Output is read from the end, where the real error usually is.
+func classifyError(program, tool string, exit *ExitError) *BackendError {
+	backendErr := &BackendError{Manager: program, Exit: exit}
+
+	// Killed by Ctrl-C, or pkexec's "dismissed" / "not authorized"
+	switch {
+	case exit.ExitCode == 130:
+		backendErr.Kind = ErrAborted
+		return backendErr
+	case tool == "pkexec" && (exit.ExitCode == 126 || exit.ExitCode == 127):
+		backendErr.Manager = tool
+		backendErr.Kind = ErrPermissionDenied
+		return backendErr
+	}
+
+	lines := strings.Split(strings.TrimSpace(exit.Stderr), "\n")
+	slices.Reverse(lines)
+
+	backend := program
+	if strings.HasPrefix(backend, "apt-") {
+		backend = "apt" // apt-get, apt-cache
+	}
+
+	groups := [][]errorPattern{backendErrorPatterns[backend], nil, commonErrorPatterns}
+	if tool != "" {
+		groups[1] = escalationErrorPatterns
+	}
+
+	for i, patterns := range groups {
+		for _, pattern := range patterns {
+			for _, line := range lines {
+				lower := strings.ToLower(line)
+				for _, fragment := range pattern.fragments {
+					if strings.Contains(lower, fragment) {
+						if i == 1 {
+							backendErr.Manager = tool
+						}
+						backendErr.Kind = pattern.kind
+						backendErr.Detail = cleanErrorLine(line)
+						return backendErr
+					}
+				}
+			}
+		}
+	}
+
+	// Nothing recognised: keep the last line as a hint
+	if len(lines) > 0 {
+		backendErr.Detail = cleanErrorLine(lines[0])
+	}
+	return backendErr
+}
+
+// cleanErrorLine strips prefixes like "E: " and "error: " from a line
+func cleanErrorLine(line string) string {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"E: ", "Error: ", "error: ", "ERROR: ", "- "} {
+		line = strings.TrimPrefix(line, prefix)
+	}
+	return line
+}
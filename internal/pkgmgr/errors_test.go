@@ -0,0 +1,404 @@
+package pkgmgr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name        string
+		program     string
+		tool        string
+		exitCode    int
+		stderr      string
+		wantKind    error
+		wantManager string
+		wantDetail  string
+	}{
+		// DNF
+		{
+			name:       "dnf locked",
+			program:    "dnf",
+			stderr:     "Waiting for process with pid 4242 to finish.\n",
+			wantKind:   ErrDatabaseLocked,
+			wantDetail: "Waiting for process with pid 4242 to finish.",
+		},
+		{
+			name:    "dnf network",
+			program: "dnf",
+			stderr: `Errors during downloading metadata for repository 'fedora':
+  - Curl error (6): Couldn't resolve host name for https://mirrors.fedoraproject.org/metalink?repo=fedora-40&arch=x86_64 [Could not resolve host: mirrors.fedoraproject.org]
+Error: Failed to download metadata for repo 'fedora': Cannot download repomd.xml: Cannot download repodata/repomd.xml: All mirrors were tried
+`,
+			wantKind:   ErrNetworkUnavailable,
+			wantDetail: "Failed to download metadata for repo 'fedora': Cannot download repomd.xml: Cannot download repodata/repomd.xml: All mirrors were tried",
+		},
+		{
+			name:    "dnf conflict",
+			program: "dnf",
+			stderr: `Error:
+ Problem: conflicting requests
+  - nothing provides libfoo.so.1()(64bit) needed by bar-1.0-1.fc40.x86_64 from copr
+`,
+			wantKind:   ErrDependencyConflict,
+			wantDetail: "nothing provides libfoo.so.1()(64bit) needed by bar-1.0-1.fc40.x86_64 from copr",
+		},
+		{
+			name:       "dnf not found",
+			program:    "dnf",
+			stderr:     "No match for argument: vmi\nError: Unable to find a match: vmi\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "Unable to find a match: vmi",
+		},
+		{
+			name:       "dnf declined",
+			program:    "dnf",
+			stderr:     "Operation aborted.\n",
+			wantKind:   ErrAborted,
+			wantDetail: "Operation aborted.",
+		},
+
+		// APT
+		{
+			name:    "apt not root",
+			program: "apt-get",
+			stderr: `E: Could not open lock file /var/lib/dpkg/lock-frontend - open (13: Permission denied)
+E: Unable to acquire the dpkg frontend lock (/var/lib/dpkg/lock-frontend), are you root?
+`,
+			wantKind:   ErrPermissionDenied,
+			wantDetail: "Unable to acquire the dpkg frontend lock (/var/lib/dpkg/lock-frontend), are you root?",
+		},
+		{
+			name:    "apt locked",
+			program: "apt-get",
+			stderr: `E: Could not get lock /var/lib/dpkg/lock-frontend. It is held by process 2345 (unattended-upgr)
+N: Be aware that removing the lock file is not a solution and may break your system.
+E: Unable to acquire the dpkg frontend lock (/var/lib/dpkg/lock-frontend), is another process using it?
+`,
+			wantKind:   ErrDatabaseLocked,
+			wantDetail: "Unable to acquire the dpkg frontend lock (/var/lib/dpkg/lock-frontend), is another process using it?",
+		},
+		{
+			name:    "apt network",
+			program: "apt-get",
+			stderr: `E: Failed to fetch http://archive.ubuntu.com/ubuntu/pool/main/v/vim/vim_9.1.0016-1ubuntu7_amd64.deb  Temporary failure resolving 'archive.ubuntu.com'
+E: Unable to fetch some archives, maybe run apt-get update or try with --fix-missing?
+`,
+			wantKind:   ErrNetworkUnavailable,
+			wantDetail: "Failed to fetch http://archive.ubuntu.com/ubuntu/pool/main/v/vim/vim_9.1.0016-1ubuntu7_amd64.deb  Temporary failure resolving 'archive.ubuntu.com'",
+		},
+		{
+			name:    "apt conflict",
+			program: "apt-get",
+			stderr: `The following packages have unmet dependencies:
+ libfoo-dev : Depends: libfoo1 (= 1.2-1) but 1.3-1 is to be installed
+E: Unable to correct problems, you have held broken packages.
+`,
+			wantKind:   ErrDependencyConflict,
+			wantDetail: "Unable to correct problems, you have held broken packages.",
+		},
+		{
+			name:       "apt disk full",
+			program:    "apt-get",
+			stderr:     "E: You don't have enough free space in /var/cache/apt/archives/.\n",
+			wantKind:   ErrDiskFull,
+			wantDetail: "You don't have enough free space in /var/cache/apt/archives/.",
+		},
+		{
+			name:       "apt not found",
+			program:    "apt-get",
+			stderr:     "E: Unable to locate package vmi\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "Unable to locate package vmi",
+		},
+		{
+			name:       "apt declined",
+			program:    "apt-get",
+			stderr:     "Abort.\n",
+			wantKind:   ErrAborted,
+			wantDetail: "Abort.",
+		},
+
+		// Pacman
+		{
+			name:    "pacman locked",
+			program: "pacman",
+			stderr: `error: failed to init transaction (unable to lock database)
+error: could not lock database: File exists
+  if you're sure a package manager is not already
+  running, you can remove /var/lib/pacman/db.lck
+`,
+			wantKind:   ErrDatabaseLocked,
+			wantDetail: "failed to init transaction (unable to lock database)",
+		},
+		{
+			name:       "pacman not root",
+			program:    "pacman",
+			stderr:     "error: you cannot perform this operation unless you are root.\n",
+			wantKind:   ErrPermissionDenied,
+			wantDetail: "you cannot perform this operation unless you are root.",
+		},
+		{
+			name:    "pacman network",
+			program: "pacman",
+			stderr: `error: failed retrieving file 'vim-9.1.0785-1-x86_64.pkg.tar.zst' from geo.mirror.pkgbuild.com : Could not resolve host: geo.mirror.pkgbuild.com
+warning: failed to retrieve some files
+error: failed to commit transaction (failed to retrieve some files)
+Errors occurred, no packages were upgraded.
+`,
+			wantKind:   ErrNetworkUnavailable,
+			wantDetail: "failed retrieving file 'vim-9.1.0785-1-x86_64.pkg.tar.zst' from geo.mirror.pkgbuild.com : Could not resolve host: geo.mirror.pkgbuild.com",
+		},
+		{
+			name:    "pacman conflict",
+			program: "pacman",
+			stderr: `error: unresolvable package conflicts detected
+error: failed to prepare transaction (conflicting dependencies)
+:: vim-9.1.0785-1 and gvim-9.1.0785-1 are in conflict
+`,
+			wantKind:   ErrDependencyConflict,
+			wantDetail: ":: vim-9.1.0785-1 and gvim-9.1.0785-1 are in conflict",
+		},
+		{
+			name:    "pacman disk full",
+			program: "pacman",
+			stderr: `error: Partition / too full: 215883 blocks needed, 10240 blocks free
+error: not enough free disk space
+error: failed to commit transaction (not enough free disk space)
+`,
+			wantKind:   ErrDiskFull,
+			wantDetail: "failed to commit transaction (not enough free disk space)",
+		},
+		{
+			name:       "pacman not found",
+			program:    "pacman",
+			stderr:     "error: target not found: vmi\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "target not found: vmi",
+		},
+
+		// Zypper
+		{
+			name:    "zypper locked",
+			program: "zypper",
+			stderr: `System management is locked by the application with pid 4242 (zypper).
+Close this application before trying again.
+`,
+			wantKind:   ErrDatabaseLocked,
+			wantDetail: "System management is locked by the application with pid 4242 (zypper).",
+		},
+		{
+			name:       "zypper network",
+			program:    "zypper",
+			stderr:     "Download (curl) error for 'https://download.opensuse.org/tumbleweed/repo/oss/repodata/repomd.xml':\nError code: Connection failed\n",
+			wantKind:   ErrNetworkUnavailable,
+			wantDetail: "Download (curl) error for 'https://download.opensuse.org/tumbleweed/repo/oss/repodata/repomd.xml':",
+		},
+		{
+			name:       "zypper conflict",
+			program:    "zypper",
+			stderr:     "Problem: 1: nothing provides 'libfoo1' needed by the to be installed bar-1.0-1.1.x86_64\n",
+			wantKind:   ErrDependencyConflict,
+			wantDetail: "Problem: 1: nothing provides 'libfoo1' needed by the to be installed bar-1.0-1.1.x86_64",
+		},
+		{
+			name:       "zypper not found",
+			program:    "zypper",
+			stderr:     "'vmi' not found in package names. Trying capabilities.\nNo provider of 'vmi' found.\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "No provider of 'vmi' found.",
+		},
+
+		// Flatpak
+		{
+			name:       "flatpak system install as user",
+			program:    "flatpak",
+			stderr:     "error: Flatpak system operation Deploy not allowed for user\n",
+			wantKind:   ErrPermissionDenied,
+			wantDetail: "Flatpak system operation Deploy not allowed for user",
+		},
+		{
+			name:       "flatpak network",
+			program:    "flatpak",
+			stderr:     "error: Unable to load summary from remote flathub: While fetching https://dl.flathub.org/repo/summary.idx: [6] Couldn't resolve host name\n",
+			wantKind:   ErrNetworkUnavailable,
+			wantDetail: "Unable to load summary from remote flathub: While fetching https://dl.flathub.org/repo/summary.idx: [6] Couldn't resolve host name",
+		},
+		{
+			name:       "flatpak missing runtime",
+			program:    "flatpak",
+			stderr:     "error: The application org.gnome.Maps/x86_64/stable requires the runtime org.gnome.Platform/x86_64/47 which was not found\n",
+			wantKind:   ErrDependencyConflict,
+			wantDetail: "The application org.gnome.Maps/x86_64/stable requires the runtime org.gnome.Platform/x86_64/47 which was not found",
+		},
+		{
+			name:       "flatpak not found",
+			program:    "flatpak",
+			stderr:     "error: Nothing matches vmi in remote flathub\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "Nothing matches vmi in remote flathub",
+		},
+		{
+			name:       "flatpak uninstall not installed",
+			program:    "flatpak",
+			stderr:     "error: org.gimp.GIMP/*unspecified*/*unspecified* not installed\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "org.gimp.GIMP/*unspecified*/*unspecified* not installed",
+		},
+
+		// Escalation tools
+		{
+			name:        "sudo wrong password",
+			program:     "dnf",
+			tool:        "sudo",
+			stderr:      "Sorry, try again.\nSorry, try again.\nsudo: 3 incorrect password attempts\n",
+			wantKind:    ErrPermissionDenied,
+			wantManager: "sudo",
+			wantDetail:  "sudo: 3 incorrect password attempts",
+		},
+		{
+			name:        "sudo not allowed",
+			program:     "apt-get",
+			tool:        "sudo",
+			stderr:      "lazylinux is not in the sudoers file.\n",
+			wantKind:    ErrPermissionDenied,
+			wantManager: "sudo",
+			wantDetail:  "lazylinux is not in the sudoers file.",
+		},
+		{
+			name:        "doas wrong password",
+			program:     "apk",
+			tool:        "doas",
+			stderr:      "doas: Authentication failed\n",
+			wantKind:    ErrPermissionDenied,
+			wantManager: "doas",
+			wantDetail:  "doas: Authentication failed",
+		},
+		{
+			name:        "run0 denied",
+			program:     "pacman",
+			tool:        "run0",
+			stderr:      "Failed to start transient service unit: Access denied\n",
+			wantKind:    ErrPermissionDenied,
+			wantManager: "run0",
+			wantDetail:  "Failed to start transient service unit: Access denied",
+		},
+		{
+			name:        "pkexec dismissed",
+			program:     "dnf",
+			tool:        "pkexec",
+			exitCode:    126,
+			stderr:      "Error executing command as another user: Request dismissed\n",
+			wantKind:    ErrPermissionDenied,
+			wantManager: "pkexec",
+		},
+		{
+			name:       "backend error behind sudo",
+			program:    "pacman",
+			tool:       "sudo",
+			stderr:     "error: target not found: vmi\n",
+			wantKind:   ErrPackageNotFound,
+			wantDetail: "target not found: vmi",
+		},
+
+		// Common to every program
+		{
+			name:       "disk full",
+			program:    "apk",
+			stderr:     "ERROR: vim-9.1.0707-r0: No space left on device\n",
+			wantKind:   ErrDiskFull,
+			wantDetail: "vim-9.1.0707-r0: No space left on device",
+		},
+		{
+			name:       "permission denied",
+			program:    "apk",
+			stderr:     "ERROR: Unable to lock database: Permission denied\nERROR: Failed to open apk database: Permission denied\n",
+			wantKind:   ErrPermissionDenied,
+			wantDetail: "Failed to open apk database: Permission denied",
+		},
+		{
+			name:       "network",
+			program:    "xbps-install",
+			stderr:     "ERROR: [reposync] failed to fetch file `https://repo-default.voidlinux.org/current/x86_64-repodata': Connection timed out\n",
+			wantKind:   ErrNetworkUnavailable,
+			wantDetail: "[reposync] failed to fetch file `https://repo-default.voidlinux.org/current/x86_64-repodata': Connection timed out",
+		},
+		{
+			name:     "interrupted",
+			program:  "dnf",
+			tool:     "sudo",
+			exitCode: 130,
+			stderr:   "Downloading Packages:\n",
+			wantKind: ErrAborted,
+		},
+
+		// Unrecognised
+		{
+			name:       "unknown error",
+			program:    "snap",
+			stderr:     "error: cannot communicate with server: Post \"http://localhost/v2/snaps/vim\": dial unix /run/snapd.socket: connect: no such file or directory\n",
+			wantKind:   nil,
+			wantDetail: "cannot communicate with server: Post \"http://localhost/v2/snaps/vim\": dial unix /run/snapd.socket: connect: no such file or directory",
+		},
+		{
+			name:     "no output",
+			program:  "dnf",
+			wantKind: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode := tt.exitCode
+			if exitCode == 0 {
+				exitCode = 1
+			}
+			exit := &ExitError{Command: tt.program, ExitCode: exitCode, Stderr: tt.stderr}
+
+			err := classifyError(tt.program, tt.tool, exit)
+
+			if err.Kind != tt.wantKind {
+				t.Errorf("Kind = %v, want %v", err.Kind, tt.wantKind)
+			}
+			wantManager := tt.wantManager
+			if wantManager == "" {
+				wantManager = tt.program
+			}
+			if err.Manager != wantManager {
+				t.Errorf("Manager = %q, want %q", err.Manager, wantManager)
+			}
+			if err.Detail != tt.wantDetail {
+				t.Errorf("Detail = %q, want %q", err.Detail, tt.wantDetail)
+			}
+
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr != exit {
+				t.Error("the exit error isn't reachable with errors.As")
+			}
+			if tt.wantKind != nil && !errors.Is(err, tt.wantKind) {
+				t.Errorf("errors.Is(%v) = false", tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestBackendErrorMessage(t *testing.T) {
+	exit := &ExitError{Command: "dnf install vmi", ExitCode: 1}
+
+	tests := []struct {
+		err  *BackendError
+		want string
+	}{
+		{&BackendError{Manager: "dnf", Kind: ErrPackageNotFound, Detail: "Unable to find a match: vmi", Exit: exit}, "package not found: Unable to find a match: vmi"},
+		{&BackendError{Manager: "pkexec", Kind: ErrPermissionDenied, Exit: exit}, "pkexec: permission denied"},
+		{&BackendError{Manager: "snap", Detail: "cannot communicate with server", Exit: exit}, "snap failed: cannot communicate with server"},
+		{&BackendError{Manager: "dnf", Exit: exit}, "dnf failed: exit status 1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package pkgmgr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Executor runs the external commands backends depend on. The default
+// runs real processes; tests swap in a FakeExecutor with SetExecutor.
+type Executor interface {
+	// Execute runs cmd to completion, wiring up its streams, and kills it
+	// when ctx is done. A command that runs but exits non-zero returns an
+	// *ExitError.
+	Execute(ctx context.Context, cmd *Command) error
+	// LookPath finds an executable on PATH
+	LookPath(name string) (string, error)
+}
+
+// Command is a command to run through the current Executor. It mirrors
+// the parts of exec.Cmd the backends use, so call sites read the same.
+type Command struct {
+	Name string
+	Args []string
+
+	Stdin  io.Reader
+	Stdout io.Writer // Discarded when nil
+	Stderr io.Writer // Discarded when nil
+
+	ctx context.Context
+
+	// lockProgram names the package manager whose locks must be free
+	// before running, set for privileged (transaction) commands
+	lockProgram string
+}
+
+// ExitError reports a command that ran but exited with a non-zero status
+type ExitError struct {
+	Command  string
+	ExitCode int
+	Stderr   string // The end of what the command wrote to stderr
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.ExitCode)
+}
+
+// commandWaitDelay is how long a cancelled command gets to exit after
+// being interrupted before it is killed
+const commandWaitDelay = 10 * time.Second
+
+var (
+	executorMu sync.RWMutex
+	executor   Executor = systemExecutor{}
+
+	// fsRoot is prepended to the system files lazylinux reads (lock
+	// files, /proc, /etc/os-release) so tests can use a fixture tree
+	fsRoot = "/"
+)
+
+// SetExecutor replaces the executor used by every backend and returns the
+// previous one so it can be restored
+func SetExecutor(e Executor) Executor {
+	executorMu.Lock()
+	defer executorMu.Unlock()
+
+	previous := executor
+	executor = e
+	return previous
+}
+
+// SetRoot makes system files be read from under dir instead of / and
+// returns the previous root
+func SetRoot(dir string) string {
+	executorMu.Lock()
+	defer executorMu.Unlock()
+
+	previous := fsRoot
+	fsRoot = dir
+	return previous
+}
+
+// hostPath resolves an absolute system path under the current root
+func hostPath(path string) string {
+	executorMu.RLock()
+	defer executorMu.RUnlock()
+	return filepath.Join(fsRoot, path)
+}
+
+func currentExecutor() Executor {
+	executorMu.RLock()
+	defer executorMu.RUnlock()
+	return executor
+}
+
+// newCommand prepares a command, like exec.CommandContext
+func newCommand(ctx context.Context, name string, args ...string) *Command {
+	return &Command{Name: name, Args: args, ctx: ctx}
+}
+
+// String returns the command line, e.g. "sudo dnf install -y vim"
+func (c *Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Run runs the command and waits for it to finish. A non-zero exit is
+// returned as a *BackendError classified from the command's stderr,
+// which is still passed through to c.Stderr.
+func (c *Command) Run() error {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if c.lockProgram != "" {
+		if err := waitForLock(ctx, c.lockProgram); err != nil {
+			return err
+		}
+	}
+
+	stderr := &tailBuffer{limit: stderrTailSize}
+	if c.Stderr != nil {
+		c.Stderr = io.MultiWriter(c.Stderr, stderr)
+	} else {
+		c.Stderr = stderr
+	}
+
+	err := currentExecutor().Execute(ctx, c)
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	exitErr.Stderr = stderr.String()
+
+	program, tool := c.Name, ""
+	if slices.Contains(EscalationTools, c.Name) && len(c.Args) > 0 {
+		program, tool = c.Args[0], c.Name
+	}
+	return classifyError(program, tool, exitErr)
+}
+
+// Output runs the command and returns its standard output
+func (c *Command) Output() ([]byte, error) {
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	return stdout.Bytes(), err
+}
+
+// stderrTailSize is how much of a command's stderr is kept for
+// classifying failures
+const stderrTailSize = 16 << 10
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.limit {
+		t.buf = t.buf[len(t.buf)-t.limit:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	return string(t.buf)
+}
+
+// commandExists checks if an executable is on PATH
+func commandExists(name string) bool {
+	_, err := currentExecutor().LookPath(name)
+	return err == nil
+}
+
+// systemExecutor runs real processes with os/exec
+type systemExecutor struct{}
+
+func (systemExecutor) Execute(ctx context.Context, c *Command) error {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+
+	// Let package managers finish their current step (and release their
+	// locks) instead of killing them outright
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = commandWaitDelay
+
+	err := cmd.Run()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Command: c.String(), ExitCode: exitErr.ExitCode()}
+	}
+	return err
+}
+
+func (systemExecutor) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
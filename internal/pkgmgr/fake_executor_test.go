@@ -0,0 +1,238 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a recorded command run replayed by FakeExecutor
+type Fixture struct {
+	Args     []string `yaml:"args"` // Full command line, e.g. [sudo, dnf, install, -y, vim]
+	Stdout   string   `yaml:"stdout,omitempty"`
+	Stderr   string   `yaml:"stderr,omitempty"`
+	ExitCode int      `yaml:"exit_code,omitempty"`
+}
+
+// fixtureFile is the on-disk format read by LoadFixtures:
+//
+//	path: [dnf, rpm]
+//	commands:
+//	  - args: [rpm, -q, vim]
+//	    stdout: "vim-9.1.0-1.fc40.x86_64\n"
+//	  - args: [rpm, -q, emacs]
+//	    stdout: "package emacs is not installed\n"
+//	    exit_code: 1
+type fixtureFile struct {
+	Path     []string  `yaml:"path"` // Executables LookPath finds
+	Commands []Fixture `yaml:"commands"`
+}
+
+// Call is a command the FakeExecutor was asked to run
+type Call struct {
+	Args  []string
+	Stdin string
+}
+
+// FakeExecutor replays fixtures instead of running processes and records
+// every call, so backends can be exercised without the tools installed.
+// Fixtures with the same arguments are replayed in order, the last one
+// repeating. Commands without a fixture fail.
+type FakeExecutor struct {
+	mu       sync.Mutex
+	paths    map[string]bool
+	fixtures []Fixture
+	replayed []bool
+	calls    []Call
+}
+
+// NewFakeExecutor creates a fake that replays the given fixtures
+func NewFakeExecutor(fixtures ...Fixture) *FakeExecutor {
+	f := &FakeExecutor{paths: map[string]bool{}}
+	f.Add(fixtures...)
+	return f
+}
+
+// LoadFixtures creates a fake from one or more fixture files
+func LoadFixtures(files ...string) (*FakeExecutor, error) {
+	f := NewFakeExecutor()
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var contents fixtureFile
+		if err := yaml.Unmarshal(data, &contents); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %v", file, err)
+		}
+
+		f.AddPath(contents.Path...)
+		f.Add(contents.Commands...)
+	}
+
+	return f, nil
+}
+
+// Add appends fixtures to replay
+func (f *FakeExecutor) Add(fixtures ...Fixture) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fixtures = append(f.fixtures, fixtures...)
+	f.replayed = append(f.replayed, make([]bool, len(fixtures))...)
+}
+
+// AddPath makes LookPath find the named executables
+func (f *FakeExecutor) AddPath(names ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, name := range names {
+		f.paths[name] = true
+	}
+}
+
+// Execute replays the fixture recorded for cmd. A cancelled ctx fails
+// before anything is replayed, like a process killed on startup.
+func (f *FakeExecutor) Execute(ctx context.Context, cmd *Command) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	args := append([]string{cmd.Name}, cmd.Args...)
+
+	call := Call{Args: args}
+	if cmd.Stdin != nil {
+		stdin, err := io.ReadAll(cmd.Stdin)
+		if err != nil {
+			return err
+		}
+		call.Stdin = string(stdin)
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	fixture, found := f.next(args)
+	f.mu.Unlock()
+
+	if !found {
+		return fmt.Errorf("fake executor: no fixture for %q", cmd.String())
+	}
+
+	if cmd.Stdout != nil {
+		io.WriteString(cmd.Stdout, fixture.Stdout)
+	}
+	if cmd.Stderr != nil {
+		io.WriteString(cmd.Stderr, fixture.Stderr)
+	}
+
+	if fixture.ExitCode != 0 {
+		return &ExitError{Command: cmd.String(), ExitCode: fixture.ExitCode}
+	}
+	return nil
+}
+
+// next picks the first fixture for args that hasn't been replayed yet,
+// falling back to the last one. f.mu must be held.
+func (f *FakeExecutor) next(args []string) (Fixture, bool) {
+	last := -1
+	for i, fixture := range f.fixtures {
+		if !slices.Equal(fixture.Args, args) {
+			continue
+		}
+		if !f.replayed[i] {
+			f.replayed[i] = true
+			return fixture, true
+		}
+		last = i
+	}
+
+	if last == -1 {
+		return Fixture{}, false
+	}
+	return f.fixtures[last], true
+}
+
+// LookPath finds executables added with AddPath or a fixture's path list
+func (f *FakeExecutor) LookPath(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.paths[name] {
+		return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return "/usr/bin/" + name, nil
+}
+
+// Calls returns every command run so far, in order
+func (f *FakeExecutor) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.calls)
+}
+
+// Unreplayed returns the fixtures no command has used yet
+func (f *FakeExecutor) Unreplayed() []Fixture {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var unused []Fixture
+	for i, fixture := range f.fixtures {
+		if !f.replayed[i] {
+			unused = append(unused, fixture)
+		}
+	}
+	return unused
+}
+
+// useFixtures replays the named files from testdata/fixtures for the rest
+// of the test. Privileged commands go through sudo and no lock is ever
+// held, so fixtures read the same on any machine.
+func useFixtures(t *testing.T, names ...string) *FakeExecutor {
+	t.Helper()
+
+	files := make([]string, len(names))
+	for i, name := range names {
+		files[i] = filepath.Join("testdata", "fixtures", name+".yaml")
+	}
+	fake, err := LoadFixtures(files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousExecutor := SetExecutor(fake)
+	previousRoot := SetRoot(t.TempDir())
+	previousIsRoot, previousTool := isRoot, escalationTool
+	isRoot = func() bool { return false }
+	if err := SetEscalationTool("sudo"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		SetExecutor(previousExecutor)
+		SetRoot(previousRoot)
+		isRoot = previousIsRoot
+		SetEscalationTool(previousTool)
+	})
+	return fake
+}
+
+// callArgs returns the command lines fake ran, in order
+func callArgs(fake *FakeExecutor) [][]string {
+	var args [][]string
+	for _, call := range fake.Calls() {
+		args = append(args, call.Args)
+	}
+	return args
+}
@@ -1,36 +1,80 @@
 package pkgmgr
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
 )
 
 // Flatpak represents the Flatpak package manager
-type Flatpak struct{}
+type Flatpak struct {
+	Scope string // "system" (shared, needs root) or "user" (~/.local/share/flatpak)
+}
+
+// NewFlatpak creates a new Flatpak instance for the given installation scope
+func NewFlatpak(scope string) *Flatpak {
+	if scope != "user" {
+		scope = "system"
+	}
+	return &Flatpak{Scope: scope}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "flatpak",
+		DisplayName:  "Flatpak",
+		Icon:         "🎨",
+		Priority:     10,
+		Capabilities: CapSearch | CapOrphans,
+		Detect:       func() bool { return isFlatpakInstalled() },
+		Enabled:      func(cfg *config.Config) bool { return cfg.FlatpakEnabled },
+		New:          func(cfg *config.Config) PackageManager { return NewFlatpak(flatpakScope(cfg)) },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (f *Flatpak) Name() string {
+	return "flatpak"
+}
 
-// NewFlatpak creates a new Flatpak instance
-func NewFlatpak() *Flatpak {
-	return &Flatpak{}
+// flatpakScope reads the configured scope, tolerating a nil config
+func flatpakScope(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.FlatpakScope
+}
+
+// command prepares a flatpak command that changes the installation.
+// Only the system installation needs root; user installs never escalate.
+func (f *Flatpak) command(ctx context.Context, args ...string) *Command {
+	args = append([]string{"--" + f.Scope}, args...)
+	if f.Scope == "user" {
+		return newCommand(ctx, "flatpak", args...)
+	}
+	return privilegedCommand(ctx, "flatpak", args...)
 }
 
 // Install installs packages via Flatpak from Flathub
-func (f *Flatpak) Install(packages ...string) error {
+func (f *Flatpak) Install(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 
-	// Flatpak command: flatpak install -y flathub <packages>
+	// Flatpak command: flatpak --<scope> install -y flathub <packages>
 	for _, pkg := range packages {
 		args := []string{"install", "-y", "flathub", pkg}
-		cmd := exec.Command("flatpak", args...)
+		cmd := f.command(ctx, args...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
 		err := cmd.Run()
 		if err != nil {
-			return fmt.Errorf("failed to install %s: %v", pkg, err)
+			return fmt.Errorf("failed to install %s: %w", pkg, err)
 		}
 	}
 
@@ -38,33 +82,33 @@ func (f *Flatpak) Install(packages ...string) error {
 }
 
 // Remove uninstalls packages from Flatpak
-func (f *Flatpak) Remove(packages ...string) error {
+func (f *Flatpak) Remove(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 
-	// Flatpak command: flatpak uninstall -y <packages>
+	// Flatpak command: flatpak --<scope> uninstall -y <packages>
 	args := append([]string{"uninstall", "-y"}, packages...)
-	cmd := exec.Command("flatpak", args...)
+	cmd := f.command(ctx, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
 // Update updates all Flatpak packages
-func (f *Flatpak) Update() error {
-	// Flatpak command: flatpak update -y
-	cmd := exec.Command("flatpak", "update", "-y")
+func (f *Flatpak) Update(ctx context.Context) error {
+	// Flatpak command: flatpak --<scope> update -y
+	cmd := f.command(ctx, "update", "-y")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
 // Clean removes unused Flatpak runtimes and cleans cache
-func (f *Flatpak) Clean() error {
+func (f *Flatpak) Clean(ctx context.Context) error {
 	// Clean unused runtimes and apps
 	fmt.Println("🧹 Removing unused Flatpak runtimes...")
-	uninstallCmd := exec.Command("flatpak", "uninstall", "--unused", "-y")
+	uninstallCmd := f.command(ctx, "uninstall", "--unused", "-y")
 	uninstallCmd.Stdout = os.Stdout
 	uninstallCmd.Stderr = os.Stderr
 	err := uninstallCmd.Run()
@@ -74,32 +118,259 @@ func (f *Flatpak) Clean() error {
 
 	// Repair installation
 	fmt.Println("🔧 Repairing Flatpak installation...")
-	repairCmd := exec.Command("flatpak", "repair", "--user")
+	repairCmd := f.command(ctx, "repair")
 	repairCmd.Stdout = os.Stdout
 	repairCmd.Stderr = os.Stderr
 	return repairCmd.Run()
 }
 
+// Simulate previews an operation from Flathub's metadata. flatpak has
+// no dry run, and --no-deploy would still download everything, so this
+// lists what would change instead; runtimes new apps pull in aren't shown.
+func (f *Flatpak) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	set := &ChangeSet{}
+
+	switch op {
+	case OpInstall:
+		for _, pkg := range packages {
+			cmd := newCommand(ctx, "flatpak", "--"+f.Scope, "remote-info", "flathub", pkg)
+			output, err := cmd.Output()
+			if err != nil {
+				return nil, fmt.Errorf("%w on Flathub: %s", ErrPackageNotFound, pkg)
+			}
+
+			fields := parseKeyValue(string(output))
+			size := parseHumanSize(fields["Download"])
+			set.Add(ActionInstall, pkg, fields["Version"], size)
+			set.DownloadSize += size
+		}
+
+	case OpRemove:
+		installed, err := f.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range packages {
+			i := slices.IndexFunc(installed, func(p Package) bool { return p.Name == pkg })
+			if i == -1 {
+				return nil, fmt.Errorf("%w: %s is not installed", ErrPackageNotFound, pkg)
+			}
+			set.Add(ActionRemove, pkg, installed[i].Version, installed[i].InstalledSize)
+		}
+
+	case OpUpdate:
+		cmd := newCommand(ctx, "flatpak", "--"+f.Scope, "remote-ls", "--updates", "--columns=application,version,download-size")
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, err
+		}
+		set = parseFlatpakUpdates(string(output))
+
+	case OpClean:
+		// Unused runtimes are only known to "flatpak uninstall --unused" itself
+		return nil, fmt.Errorf("%w: Flatpak only finds unused runtimes while removing them", ErrSimulationUnsupported)
+	}
+
+	return set, nil
+}
+
+// parseFlatpakUpdates parses tab separated "flatpak remote-ls --updates"
+// output:
+//
+//	org.mozilla.firefox	129.0	98.2 MB
+func parseFlatpakUpdates(output string) *ChangeSet {
+	set := &ChangeSet{}
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Split(line, "\t")
+		appID := strings.TrimSpace(parts[0])
+		if appID == "" || appID == "Application ID" {
+			continue
+		}
+		for len(parts) < 3 {
+			parts = append(parts, "")
+		}
+
+		size := parseHumanSize(parts[2])
+		set.Add(ActionUpgrade, appID, strings.TrimSpace(parts[1]), size)
+		set.DownloadSize += size
+	}
+
+	return set
+}
+
 // List lists all installed Flatpak packages
-func (f *Flatpak) List() ([]string, error) {
-	// flatpak list --app --columns=application
-	cmd := exec.Command("flatpak", "list", "--app", "--columns=application")
+func (f *Flatpak) List(ctx context.Context) ([]Package, error) {
+	cmd := newCommand(ctx, "flatpak", "list", "--app", "--columns=application,name,version,branch,arch,origin,size")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse output
-	var results []string
-	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+	return parseFlatpakList(string(output)), nil
+}
+
+// parseFlatpakList parses tab separated "flatpak list" output:
+//
+//	org.mozilla.firefox	Firefox	128.0	stable	x86_64	flathub	245.3 MB
+func parseFlatpakList(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
 
 	for line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || line == "Application ID" {
+		parts := strings.Split(line, "\t")
+		appID := strings.TrimSpace(parts[0])
+		if appID == "" || appID == "Application ID" {
 			continue
 		}
-		results = append(results, line)
+
+		// Pad missing trailing columns
+		for len(parts) < 7 {
+			parts = append(parts, "")
+		}
+
+		// Remote and branch, e.g. "flathub/stable"
+		repo := strings.TrimSpace(parts[5])
+		if branch := strings.TrimSpace(parts[3]); branch != "" {
+			repo += "/" + branch
+		}
+
+		results = append(results, Package{
+			Name:          appID,
+			DisplayName:   strings.TrimSpace(parts[1]),
+			Version:       strings.TrimSpace(parts[2]),
+			Arch:          strings.TrimSpace(parts[4]),
+			Repository:    repo,
+			Source:        "flatpak",
+			InstalledSize: parseHumanSize(parts[6]),
+			Reason:        ReasonExplicit, // --app skips runtimes pulled in as dependencies
+		})
 	}
 
-	return results, nil
+	return results
+}
+
+// Search searches Flathub for term
+func (f *Flatpak) Search(ctx context.Context, term string) ([]Package, error) {
+	cmd := newCommand(ctx, "flatpak", "search", "--columns=application,name,description,version,remotes", term)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search Flatpak: %w", err)
+	}
+
+	return parseFlatpakApps(string(output)), nil
+}
+
+// Index lists every app the configured remotes offer
+func (f *Flatpak) Index(ctx context.Context) ([]Package, error) {
+	cmd := newCommand(ctx, "flatpak", "remote-ls", "--app", "--columns=application,name,description,version,origin")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Flatpak apps: %w", err)
+	}
+
+	return parseFlatpakApps(string(output)), nil
+}
+
+// parseFlatpakApps parses app listings with the columns application, name,
+// description, version and remote:
+//
+//	org.zen_browser.zen	Zen Browser	Welcome to a calmer internet	1.0	flathub
+func parseFlatpakApps(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 {
+			continue
+		}
+		for len(parts) < 5 {
+			parts = append(parts, "")
+		}
+
+		// Skip if appID looks invalid ("No matches found" and similar)
+		appID := strings.TrimSpace(parts[0])
+		if appID == "" || !strings.Contains(appID, ".") {
+			continue
+		}
+
+		results = append(results, Package{
+			Name:        appID,
+			DisplayName: strings.TrimSpace(parts[1]),
+			Summary:     strings.TrimSpace(parts[2]),
+			Version:     strings.TrimSpace(parts[3]),
+			Repository:  strings.TrimSpace(parts[4]),
+			Source:      "flatpak",
+		})
+	}
+
+	return results
+}
+
+// Info returns details for an installed app, or for one on Flathub
+func (f *Flatpak) Info(ctx context.Context, name string) (*Package, error) {
+	installed := true
+	output, err := newCommand(ctx, "flatpak", "info", name).Output()
+	if err != nil {
+		installed = false
+		output, err = newCommand(ctx, "flatpak", "remote-info", "flathub", name).Output()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+		}
+	}
+
+	pkg := parseFlatpakInfo(string(output))
+	pkg.Installed = installed
+	return &pkg, nil
+}
+
+// IsAvailable checks Flathub for an exact application ID
+func (f *Flatpak) IsAvailable(ctx context.Context, name string) bool {
+	cmd := newCommand(ctx, "flatpak", "remote-info", "flathub", name)
+	return cmd.Run() == nil
+}
+
+// IsInstalled checks whether an application ID is installed
+func (f *Flatpak) IsInstalled(ctx context.Context, name string) bool {
+	cmd := newCommand(ctx, "flatpak", "info", name)
+	return cmd.Run() == nil
+}
+
+// parseFlatpakInfo parses "flatpak info" and "flatpak remote-info" output:
+//
+//	Firefox - Fast, Private & Safe Web Browser
+//
+//	          ID: org.mozilla.firefox
+//	        Arch: x86_64
+//	      Branch: stable
+//	     Version: 128.0
+//	      Origin: flathub
+//	   Installed: 245.3 MB
+func parseFlatpakInfo(output string) Package {
+	fields := parseKeyValue(output)
+
+	pkg := Package{
+		Name:          fields["ID"],
+		Version:       fields["Version"],
+		Arch:          fields["Arch"],
+		Repository:    fields["Origin"],
+		Source:        "flatpak",
+		InstalledSize: parseHumanSize(fields["Installed"]),
+	}
+	if pkg.Repository == "" {
+		pkg.Repository = "flathub"
+	}
+	if branch := fields["Branch"]; branch != "" {
+		pkg.Repository += "/" + branch
+	}
+
+	// The title line is "Name - Summary"
+	title, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	displayName, summary, _ := strings.Cut(title, " - ")
+	pkg.DisplayName = strings.TrimSpace(displayName)
+	pkg.Summary = strings.TrimSpace(summary)
+
+	return pkg
 }
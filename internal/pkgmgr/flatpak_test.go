@@ -0,0 +1,164 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFlatpakList(t *testing.T) {
+	useFixtures(t, "flatpak")
+
+	packages, err := NewFlatpak("system").List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "org.mozilla.firefox", DisplayName: "Firefox", Version: "131.0.3", Arch: "x86_64", Repository: "flathub/stable", Source: "flatpak", InstalledSize: 274_600_000, Reason: ReasonExplicit},
+		{Name: "com.spotify.Client", DisplayName: "Spotify", Version: "1.2.47.366.g2c5d15bb", Arch: "x86_64", Repository: "flathub/stable", Source: "flatpak", InstalledSize: 332_100_000, Reason: ReasonExplicit},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestParseFlatpakList(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Package
+	}{
+		{
+			name:   "header",
+			output: "Application ID\tName\tVersion\tBranch\tArch\tOrigin\tInstalled size\n",
+			want:   nil,
+		},
+		{
+			name:   "missing columns",
+			output: "org.gnome.Calculator\tCalculator\n",
+			want: []Package{
+				{Name: "org.gnome.Calculator", DisplayName: "Calculator", Source: "flatpak", Reason: ReasonExplicit},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFlatpakList(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFlatpakList() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatpakSearch(t *testing.T) {
+	useFixtures(t, "flatpak")
+
+	tests := []struct {
+		term string
+		want []Package
+	}{
+		{
+			term: "zen",
+			want: []Package{
+				{Name: "app.zen_browser.zen", DisplayName: "Zen Browser", Summary: "Experience tranquillity while browsing the web", Version: "1.0.1-a.12", Repository: "flathub", Source: "flatpak"},
+			},
+		},
+		{
+			term: "doesnotexist",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			got, err := NewFlatpak("system").Search(context.Background(), tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) =\n%+v\nwant\n%+v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlatpakApps(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Package
+	}{
+		{
+			name:   "no matches",
+			output: "No matches found\n",
+			want:   nil,
+		},
+		{
+			name:   "missing columns",
+			output: "org.gnome.Calculator\tCalculator\n",
+			want: []Package{
+				{Name: "org.gnome.Calculator", DisplayName: "Calculator", Source: "flatpak"},
+			},
+		},
+		{
+			name:   "remote-ls",
+			output: "org.videolan.VLC\tVLC\tVLC media player\t3.0.21\tflathub\norg.gimp.GIMP\tGNU Image Manipulation Program\tCreate images and edit photographs\t2.10.38\tflathub\n",
+			want: []Package{
+				{Name: "org.videolan.VLC", DisplayName: "VLC", Summary: "VLC media player", Version: "3.0.21", Repository: "flathub", Source: "flatpak"},
+				{Name: "org.gimp.GIMP", DisplayName: "GNU Image Manipulation Program", Summary: "Create images and edit photographs", Version: "2.10.38", Repository: "flathub", Source: "flatpak"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFlatpakApps(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFlatpakApps() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlatpakUpdates(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *ChangeSet
+	}{
+		{
+			name:   "up to date",
+			output: "",
+			want:   &ChangeSet{},
+		},
+		{
+			name:   "app and runtime",
+			output: "Application ID\tVersion\tDownload size\norg.mozilla.firefox\t132.0\t98.2 MB\norg.gnome.Platform\t\t312.4 MB\n",
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionUpgrade, Name: "org.mozilla.firefox", Version: "132.0", Size: 98_200_000},
+					{Action: ActionUpgrade, Name: "org.gnome.Platform", Size: 312_400_000},
+				},
+				DownloadSize: 410_600_000,
+			},
+		},
+		{
+			name:   "no size",
+			output: "org.gimp.GIMP\t2.10.38\n",
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionUpgrade, Name: "org.gimp.GIMP", Version: "2.10.38"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFlatpakUpdates(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFlatpakUpdates() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package pkgmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// Indexer is implemented by backends that can list every package they
+// offer. lazylinux caches the list so lookups can skip the live query.
+type Indexer interface {
+	Index(ctx context.Context) ([]Package, error)
+}
+
+// ErrIndexUnsupported is returned for backends that can't list every
+// package they offer
+var ErrIndexUnsupported = errors.New("can't be cached")
+
+// packageIndex is the cached list of packages one source offers
+type packageIndex struct {
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Packages  []Package `json:"packages"`
+
+	names map[string]bool
+}
+
+var (
+	indexMu  sync.Mutex
+	indexTTL = config.DefaultCacheTTL
+	indexes  = map[string]*packageIndex{} // Loaded so far, nil when missing or unreadable
+)
+
+// summarySearches are the sources whose own search matches summaries as
+// well as names; the rest match names only, and so do their indexes
+var summarySearches = map[string]bool{
+	"pacman":  true, // pacman -Ss
+	"flatpak": true, // flatpak search, which also matches app names
+}
+
+// newPackageIndex builds the index of the packages source offers
+func newPackageIndex(source string, updatedAt time.Time, packages []Package) *packageIndex {
+	index := &packageIndex{Source: source, UpdatedAt: updatedAt, Packages: packages}
+	index.names = make(map[string]bool, len(packages))
+	for _, pkg := range packages {
+		index.names[pkg.Name] = true
+	}
+	return index
+}
+
+// SetCacheTTL sets how long cached indexes are used; 0 keeps the default
+func SetCacheTTL(ttl time.Duration) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	if ttl > 0 {
+		indexTTL = ttl
+	}
+}
+
+// indexPath returns where the index for source is cached
+func indexPath(source string) string {
+	return filepath.Join(config.CacheDir(), "index", source+".json")
+}
+
+// RefreshIndex rebuilds the cached index of everything pm offers and
+// returns how many packages it holds
+func RefreshIndex(ctx context.Context, pm PackageManager) (int, error) {
+	indexer, ok := pm.(Indexer)
+	if !ok {
+		return 0, fmt.Errorf("%s %w", DisplayName(pm), ErrIndexUnsupported)
+	}
+
+	packages, err := indexer.Index(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s packages: %w", DisplayName(pm), err)
+	}
+
+	index := newPackageIndex(pm.Name(), time.Now(), packages)
+	if err := index.save(); err != nil {
+		return 0, err
+	}
+
+	indexMu.Lock()
+	indexes[pm.Name()] = index
+	indexMu.Unlock()
+	return len(packages), nil
+}
+
+// save writes the index through a temporary file, so readers never see
+// half of it
+func (ix *packageIndex) save() error {
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return fmt.Errorf("could not encode package index: %v", err)
+	}
+
+	path := indexPath(ix.Source)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create cache directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("could not write package index: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// freshIndex returns the cached index for source, or nil when there is
+// none or it is older than the TTL
+func freshIndex(source string) *packageIndex {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	index, loaded := indexes[source]
+	if !loaded {
+		index = loadIndex(source)
+		indexes[source] = index
+	}
+
+	if index == nil || time.Since(index.UpdatedAt) > indexTTL {
+		return nil
+	}
+	return index
+}
+
+// loadIndex reads a cached index from disk, nil if it can't
+func loadIndex(source string) *packageIndex {
+	data, err := os.ReadFile(indexPath(source))
+	if err != nil {
+		return nil
+	}
+
+	var cached packageIndex
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil // A broken cache is rebuilt on the next refresh
+	}
+	return newPackageIndex(cached.Source, cached.UpdatedAt, cached.Packages)
+}
+
+// search returns the packages whose name contains term, matching display
+// names and summaries too for sources whose own search does, so answers
+// don't change with the cache
+func (ix *packageIndex) search(term string) []Package {
+	term = strings.ToLower(term)
+	summaries := summarySearches[ix.Source]
+
+	matches := []Package{}
+	for _, pkg := range ix.Packages {
+		if strings.Contains(strings.ToLower(pkg.Name), term) ||
+			summaries && (strings.Contains(strings.ToLower(pkg.DisplayName), term) ||
+				strings.Contains(strings.ToLower(pkg.Summary), term)) {
+			matches = append(matches, pkg)
+		}
+	}
+	return matches
+}
+
+// isAvailable answers from a fresh index when it has the package. A miss
+// still asks the source, since the index may predate the package.
+func isAvailable(ctx context.Context, pm PackageManager, name string) bool {
+	if index := freshIndex(pm.Name()); index != nil && index.names[name] {
+		return true
+	}
+	return pm.IsAvailable(ctx, name)
+}
+
+// searchPackages searches a fresh index, falling back to the source's
+// own search when the index is stale or finds nothing. The index doesn't
+// know what is installed, so matches are marked from the installed list,
+// and a source that can't list falls back too.
+func searchPackages(ctx context.Context, pm PackageManager, term string) ([]Package, error) {
+	if index := freshIndex(pm.Name()); index != nil {
+		if matches := index.search(term); len(matches) > 0 {
+			if installed, err := installedNames(ctx, pm); err == nil {
+				for i := range matches {
+					matches[i].Installed = installed[matches[i].Name]
+				}
+				return matches, nil
+			}
+		}
+	}
+	return pm.Search(ctx, term)
+}
+
+// installedList is what one source has installed
+type installedList struct {
+	once  sync.Once
+	names map[string]bool
+	err   error
+}
+
+var (
+	installedMu    sync.Mutex
+	installedLists = map[string]*installedList{}
+)
+
+// installedNames lists what pm has installed, once per run however many
+// lookups ask, since listing takes longer than searching the index
+func installedNames(ctx context.Context, pm PackageManager) (map[string]bool, error) {
+	installedMu.Lock()
+	list, ok := installedLists[pm.Name()]
+	if !ok {
+		list = &installedList{}
+		installedLists[pm.Name()] = list
+	}
+	installedMu.Unlock()
+
+	list.once.Do(func() {
+		var versions map[string]string
+		versions, list.err = InstalledVersions(ctx, pm)
+		list.names = make(map[string]bool, len(versions))
+		for name := range versions {
+			list.names[name] = true
+		}
+	})
+	return list.names, list.err
+}
@@ -0,0 +1,197 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useCache keeps package indexes in a temporary cache directory for the
+// rest of the test
+func useCache(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	reset := func() {
+		indexMu.Lock()
+		indexes = map[string]*packageIndex{}
+		indexMu.Unlock()
+
+		installedMu.Lock()
+		installedLists = map[string]*installedList{}
+		installedMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// dnfIndexFixtures answer a DNF index refresh with htop and btop, and the
+// installed list with htop
+var dnfIndexFixtures = []Fixture{
+	{
+		Args:   []string{"dnf", "repoquery", "--quiet", "--latest-limit=1", "--queryformat", dnfQueryFormat},
+		Stdout: "htop\t3.3.0\t3.fc40\tx86_64\tfedora\tInteractive process viewer\thttps://htop.dev\nbtop\t1.3.2\t2.fc40\tx86_64\tfedora\tResource monitor\thttps://github.com/aristocratos/btop\n",
+	},
+	{
+		Args:   []string{"rpm", "-qa", "--queryformat", rpmQueryFormat},
+		Stdout: "htop\t3.3.0\t3.fc40\tx86_64\t400000\n",
+	},
+}
+
+func TestRefreshIndexAnswersLookups(t *testing.T) {
+	useCache(t)
+	fake := useFixtures(t)
+	fake.Add(dnfIndexFixtures...)
+
+	dnf := NewDNF()
+	if _, err := RefreshIndex(context.Background(), dnf); err != nil {
+		t.Fatal(err)
+	}
+	refreshCalls := len(fake.Calls())
+
+	// Straight from the refreshed index, and from the one on disk
+	for _, loaded := range []string{"refreshed", "cached"} {
+		if loaded == "cached" {
+			indexMu.Lock()
+			indexes = map[string]*packageIndex{}
+			indexMu.Unlock()
+		}
+		if !isAvailable(context.Background(), dnf, "btop") {
+			t.Errorf("isAvailable(btop) = false with a %s index", loaded)
+		}
+	}
+	if calls := callArgs(fake)[refreshCalls:]; len(calls) != 0 {
+		t.Errorf("isAvailable() ran %q, want the index to answer", calls)
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	packages := []Package{
+		{Name: "htop", Summary: "Interactive process viewer"},
+		{Name: "org.gnome.Loupe", DisplayName: "Image Viewer", Summary: "View images"},
+	}
+
+	tests := []struct {
+		source string
+		term   string
+		want   []string
+	}{
+		{source: "dnf", term: "HTOP", want: []string{"htop"}},
+		{source: "dnf", term: "viewer", want: nil},
+		{source: "apt", term: "process", want: nil},
+		{source: "pacman", term: "process", want: []string{"htop"}},
+		{source: "flatpak", term: "viewer", want: []string{"htop", "org.gnome.Loupe"}},
+		{source: "flatpak", term: "loupe", want: []string{"org.gnome.Loupe"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, pkg := range newPackageIndex(tt.source, time.Now(), packages).search(tt.term) {
+			got = append(got, pkg.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s index search(%q) = %q, want %q", tt.source, tt.term, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPackagesListsInstalledOnce(t *testing.T) {
+	useCache(t)
+	fake := useFixtures(t)
+	fake.Add(dnfIndexFixtures...)
+
+	dnf := NewDNF()
+	if _, err := RefreshIndex(context.Background(), dnf); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for _, term := range []string{"htop", "btop", "top", "htop"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := searchPackages(context.Background(), dnf, term); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lists := 0
+	for _, call := range callArgs(fake) {
+		if call[0] == "rpm" {
+			lists++
+		}
+	}
+	if lists != 1 {
+		t.Errorf("searchPackages() listed installed packages %d times, want once", lists)
+	}
+}
+
+func TestSearchPackagesFromIndex(t *testing.T) {
+	useCache(t)
+	fake := useFixtures(t)
+	fake.Add(dnfIndexFixtures...)
+
+	dnf := NewDNF()
+	if count, err := RefreshIndex(context.Background(), dnf); err != nil || count != 2 {
+		t.Fatalf("RefreshIndex() = %d, %v, want 2 packages", count, err)
+	}
+
+	matches, err := searchPackages(context.Background(), dnf, "top")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installed := map[string]bool{}
+	for _, pkg := range matches {
+		installed[pkg.Name] = pkg.Installed
+	}
+	if want := map[string]bool{"htop": true, "btop": false}; !reflect.DeepEqual(installed, want) {
+		t.Errorf("searchPackages() installed = %v, want %v", installed, want)
+	}
+
+	for _, call := range callArgs(fake) {
+		if call[0] == "dnf" && call[1] == "repoquery" && len(call) > 6 {
+			t.Errorf("searchPackages() queried the repositories with a fresh index: %q", call)
+		}
+	}
+}
+
+func TestSearchPackagesWithoutInstalledList(t *testing.T) {
+	useCache(t)
+	fake := useFixtures(t)
+	fake.Add(
+		Fixture{
+			Args:   []string{"dnf", "repoquery", "--quiet", "--latest-limit=1", "--queryformat", dnfQueryFormat},
+			Stdout: "htop\t3.3.0\t3.fc40\tx86_64\tfedora\tInteractive process viewer\thttps://htop.dev\n",
+		},
+		Fixture{
+			Args:     []string{"rpm", "-qa", "--queryformat", rpmQueryFormat},
+			ExitCode: 1,
+		},
+		Fixture{
+			Args:     []string{"dnf", "list", "--installed"},
+			ExitCode: 1,
+		},
+		Fixture{
+			Args:   []string{"dnf", "repoquery", "--quiet", "--latest-limit=1", "--queryformat", dnfQueryFormat, "*htop*"},
+			Stdout: "htop\t3.3.0\t3.fc40\tx86_64\tfedora\tInteractive process viewer\thttps://htop.dev\n",
+		},
+	)
+
+	dnf := NewDNF()
+	if _, err := RefreshIndex(context.Background(), dnf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := searchPackages(context.Background(), dnf, "htop"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := callArgs(fake)
+	if last := calls[len(calls)-1]; last[len(last)-1] != "*htop*" {
+		t.Errorf("searchPackages() ran %q last, want the live search", last)
+	}
+}
@@ -1,18 +1,18 @@
 package pkgmgr
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 )
 
-func enableSources(prefs SourcePreferences) error {
+func enableSources(ctx context.Context, prefs SourcePreferences) error {
 	distro := detectDistribution()
 
 	if prefs.Flatpak {
 		fmt.Print("📦 Installing Flatpak... ")
-		if err := installFlatpak(distro); err != nil {
+		if err := installFlatpak(ctx, distro); err != nil {
 			fmt.Printf("❌ Failed: %v\n", err)
 			return err
 		}
@@ -21,7 +21,7 @@ func enableSources(prefs SourcePreferences) error {
 
 	if prefs.Snap {
 		fmt.Print("📦 Installing Snap... ")
-		if err := installSnap(distro); err != nil {
+		if err := installSnap(ctx, distro); err != nil {
 			fmt.Printf("❌ Failed: %v\n", err)
 			return err
 		}
@@ -30,7 +30,7 @@ func enableSources(prefs SourcePreferences) error {
 
 	if prefs.RPM {
 		fmt.Print("📦 Installing RPM support... ")
-		if err := installRPM(distro); err != nil {
+		if err := installRPM(ctx, distro); err != nil {
 			fmt.Printf("❌ Failed: %v\n", err)
 			return err
 		}
@@ -40,17 +40,23 @@ func enableSources(prefs SourcePreferences) error {
 	return nil
 }
 
-func installFlatpak(distro string) error {
-	var cmd *exec.Cmd
+func installFlatpak(ctx context.Context, distro string) error {
+	var cmd *Command
 	distro = strings.ToLower(distro)
 
 	switch {
 	case strings.Contains(distro, "ubuntu") || strings.Contains(distro, "debian"):
-		cmd = exec.Command("sudo", "apt-get", "install", "-y", "flatpak")
+		cmd = privilegedCommand(ctx, "apt-get", "install", "-y", "flatpak")
 	case strings.Contains(distro, "fedora"):
-		cmd = exec.Command("sudo", "dnf", "install", "-y", "flatpak")
+		cmd = privilegedCommand(ctx, "dnf", "install", "-y", "flatpak")
 	case strings.Contains(distro, "arch"):
-		cmd = exec.Command("sudo", "pacman", "-S", "--noconfirm", "flatpak")
+		cmd = privilegedCommand(ctx, "pacman", "-S", "--noconfirm", "flatpak")
+	case strings.Contains(distro, "suse"):
+		cmd = privilegedCommand(ctx, "zypper", "--non-interactive", "install", "flatpak")
+	case strings.Contains(distro, "alpine"):
+		cmd = privilegedCommand(ctx, "apk", "add", "flatpak")
+	case strings.Contains(distro, "void"):
+		cmd = privilegedCommand(ctx, "xbps-install", "-Sy", "flatpak")
 	default:
 		return fmt.Errorf("unsupported distribution: %s", distro)
 	}
@@ -60,17 +66,31 @@ func installFlatpak(distro string) error {
 	return cmd.Run()
 }
 
-func installSnap(distro string) error {
-	var cmd *exec.Cmd
+func installSnap(ctx context.Context, distro string) error {
+	var cmd *Command
 	distro = strings.ToLower(distro)
 
 	switch {
 	case strings.Contains(distro, "ubuntu") || strings.Contains(distro, "debian"):
-		cmd = exec.Command("sudo", "apt-get", "install", "-y", "snapd")
+		cmd = privilegedCommand(ctx, "apt-get", "install", "-y", "snapd")
 	case strings.Contains(distro, "fedora"):
-		cmd = exec.Command("sudo", "dnf", "install", "-y", "snapd")
+		cmd = privilegedCommand(ctx, "dnf", "install", "-y", "snapd")
 	case strings.Contains(distro, "arch"):
-		cmd = exec.Command("sudo", "pacman", "-S", "--noconfirm", "snapd")
+		cmd = privilegedCommand(ctx, "pacman", "-S", "--noconfirm", "snapd")
+	case strings.Contains(distro, "suse"):
+		// snapd is not in the main openSUSE repositories
+		repo := "https://download.opensuse.org/repositories/system:/snappy/openSUSE_Leap_$releasever"
+		if isRollingSUSE(distro) {
+			repo = "https://download.opensuse.org/repositories/system:/snappy/openSUSE_Tumbleweed"
+		}
+		repoCmd := privilegedCommand(ctx, "zypper", "--non-interactive", "--gpg-auto-import-keys",
+			"addrepo", "--refresh", repo, "snappy")
+		repoCmd.Stdout = os.Stdout
+		repoCmd.Stderr = os.Stderr
+		if err := repoCmd.Run(); err != nil {
+			return err
+		}
+		cmd = privilegedCommand(ctx, "zypper", "--non-interactive", "--gpg-auto-import-keys", "install", "snapd")
 	default:
 		return fmt.Errorf("unsupported distribution: %s", distro)
 	}
@@ -80,33 +100,33 @@ func installSnap(distro string) error {
 	return cmd.Run()
 }
 
-func installRPM(distro string) error {
-	var cmd *exec.Cmd
+func installRPM(ctx context.Context, distro string) error {
+	var cmd *Command
 	distro = strings.ToLower(distro)
 
 	switch {
 	case strings.Contains(distro, "fedora"):
 		fmt.Println("📦 Enabling RPM Fusion repositories... ")
-		cmd = exec.Command("sudo", "dnf", "install", "-y",
+		cmd = privilegedCommand(ctx, "dnf", "install", "-y",
 			"https://mirrors.rpmfusion.org/free/fedora/rpmfusion-free-release-$(rpm -E %fedora).noarch.rpm",
 			"https://mirrors.rpmfusion.org/nonfree/fedora/rpmfusion-nonfree-release-$(rpm -E %fedora).noarch.rpm")
 		return cmd.Run()
 
 	case strings.Contains(distro, "rhel") || strings.Contains(distro, "centos"):
-		cmd = exec.Command("sudo", "dnf", "install", "-y", "--nogpgcheck",
+		cmd = privilegedCommand(ctx, "dnf", "install", "-y", "--nogpgcheck",
 			"https://dl.fedoraproject.org/pub/epel/epel-release-latest-$(rpm -E %rhel).noarch.rpm",
 			"https://mirrors.rpmfusion.org/free/el/rpmfusion-free-release-$(rpm -E %rhel).noarch.rpm",
 			"https://mirrors.rpmfusion.org/nonfree/el/rpmfusion-nonfree-release-$(rpm -E %rhel).noarch.rpm")
 		return cmd.Run()
 
 	case strings.Contains(distro, "ubuntu") || strings.Contains(distro, "debian"):
-		cmd = exec.Command("sudo", "apt-get", "install", "-y", "alien")
+		cmd = privilegedCommand(ctx, "apt-get", "install", "-y", "alien")
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		return cmd.Run()
 
 	case strings.Contains(distro, "arch"):
-		cmd = exec.Command("sudo", "pacman", "-S", "--noconfirm", "yay")
+		cmd = privilegedCommand(ctx, "pacman", "-S", "--noconfirm", "yay")
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		return cmd.Run()
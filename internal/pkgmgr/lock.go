@@ -0,0 +1,309 @@
+package pkgmgr
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// LockKind says how a package manager's lock file is held
+type LockKind int
+
+const (
+	// LockFcntl files always exist; they are locked with fcntl/flock
+	LockFcntl LockKind = iota
+	// LockExists files are locked by existing (pacman's db.lck)
+	LockExists
+	// LockPIDFile files hold the PID of the running process
+	LockPIDFile
+)
+
+// LockFile is a lock a package manager takes for a transaction
+type LockFile struct {
+	Path    string
+	Kind    LockKind
+	Holders []string // Processes that take a LockExists lock
+}
+
+// transactionLocks lists the locks each program takes, keyed by the
+// program privileged commands run
+var transactionLocks = map[string][]LockFile{
+	"apt":     aptLocks,
+	"apt-get": aptLocks,
+	"dnf":     append([]LockFile{{Path: "/var/lib/dnf/rpmdb_lock.pid", Kind: LockPIDFile}}, rpmLocks...),
+	"zypper":  append([]LockFile{{Path: "/run/zypp.pid", Kind: LockPIDFile}}, rpmLocks...),
+	"pacman": {
+		{Path: "/var/lib/pacman/db.lck", Kind: LockExists, Holders: []string{"pacman", "yay", "paru", "pamac-daemon", "packagekitd"}},
+	},
+	"apk": {
+		{Path: "/lib/apk/db/lock", Kind: LockFcntl},
+	},
+}
+
+var aptLocks = []LockFile{
+	{Path: "/var/lib/dpkg/lock-frontend", Kind: LockFcntl},
+	{Path: "/var/lib/dpkg/lock", Kind: LockFcntl},
+	{Path: "/var/lib/apt/lists/lock", Kind: LockFcntl},
+	{Path: "/var/cache/apt/archives/lock", Kind: LockFcntl},
+}
+
+// rpmLocks cover PackageKit and anything else going through librpm
+var rpmLocks = []LockFile{
+	{Path: "/usr/lib/sysimage/rpm/.rpm.lock", Kind: LockFcntl},
+	{Path: "/var/lib/rpm/.rpm.lock", Kind: LockFcntl},
+}
+
+// LockHolder describes a lock that is currently taken
+type LockHolder struct {
+	Path    string
+	PID     int    // 0 when no process could be found
+	Process string // Command name from /proc, e.g. "unattended-upgr"
+}
+
+func (h LockHolder) String() string {
+	if h.PID == 0 {
+		return fmt.Sprintf("%s exists but no running package manager holds it (remove it if it is stale)", h.Path)
+	}
+	return fmt.Sprintf("%s (pid %d) holds %s", h.Process, h.PID, h.Path)
+}
+
+var (
+	lockMu      sync.RWMutex
+	lockTimeout = 5 * time.Minute
+	lockNoWait  bool
+)
+
+// SetLockWait configures how transactions wait for a busy package
+// manager: up to timeout, or not at all with noWait
+func SetLockWait(timeout time.Duration, noWait bool) {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+	lockTimeout = timeout
+	lockNoWait = noWait
+}
+
+// FindLockHolder checks the locks program takes and returns the first
+// one that is held
+func FindLockHolder(program string) (*LockHolder, bool) {
+	for _, lock := range transactionLocks[program] {
+		if holder, held := checkLock(lock); held {
+			return holder, true
+		}
+	}
+	return nil, false
+}
+
+// waitForLock blocks until program's locks are free, showing a spinner
+// while another process holds them
+func waitForLock(ctx context.Context, program string) error {
+	holder, held := FindLockHolder(program)
+	if !held {
+		return nil
+	}
+
+	lockMu.RLock()
+	timeout, noWait := lockTimeout, lockNoWait
+	lockMu.RUnlock()
+
+	// Nobody to wait for: a stale lock never goes away on its own
+	if noWait || holder.PID == 0 {
+		return fmt.Errorf("%w: %s", ErrDatabaseLocked, holder)
+	}
+
+	fmt.Printf("⏳ %s, waiting up to %s...\n", holder, timeout)
+
+	spinner := newSpinner()
+	defer spinner.stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	start := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("%w: %s after waiting %s", ErrDatabaseLocked, holder, timeout)
+		case <-ticker.C:
+			current, held := FindLockHolder(program)
+			if !held {
+				spinner.stop()
+				fmt.Println("✅ Lock released")
+				return nil
+			}
+			holder = current
+			spinner.update(fmt.Sprintf("Waiting for %s (pid %d)... %s", holder.Process, holder.PID, time.Since(start).Round(time.Second)))
+		}
+	}
+}
+
+// checkLock reports whether a lock file is currently held
+func checkLock(lock LockFile) (*LockHolder, bool) {
+	path := hostPath(lock.Path)
+
+	switch lock.Kind {
+	case LockExists:
+		if _, err := os.Stat(path); err != nil {
+			return nil, false
+		}
+		holder := &LockHolder{Path: lock.Path}
+		holder.PID, holder.Process = findProcess(lock.Holders)
+		return holder, true
+
+	case LockPIDFile:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, false
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || !processExists(pid) {
+			return nil, false // Empty or stale, the tools ignore these too
+		}
+		return &LockHolder{Path: lock.Path, PID: pid, Process: processName(pid)}, true
+
+	default:
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, false
+		}
+		pid := fcntlLockHolder(info)
+		if pid == 0 {
+			return nil, false
+		}
+		return &LockHolder{Path: lock.Path, PID: pid, Process: processName(pid)}, true
+	}
+}
+
+// fcntlLockHolder finds the process locking a file through /proc/locks,
+// which anyone can read, unlike other users' file descriptors. Lines look
+// like "1: POSIX  ADVISORY  WRITE 1234 08:01:131090 0 EOF" and are matched
+// on the file's device (major:minor in hex) and inode.
+func fcntlLockHolder(info os.FileInfo) int {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	major, minor := deviceNumbers(uint64(stat.Dev))
+
+	file, err := os.Open(hostPath("/proc/locks"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[1] == "->" {
+			continue // Blocked waiters are listed with "->"
+		}
+
+		id := strings.Split(fields[5], ":")
+		if len(id) != 3 || id[2] != strconv.FormatUint(stat.Ino, 10) {
+			continue
+		}
+		lockMajor, errMajor := strconv.ParseUint(id[0], 16, 64)
+		lockMinor, errMinor := strconv.ParseUint(id[1], 16, 64)
+		if errMajor != nil || errMinor != nil || lockMajor != major || lockMinor != minor {
+			continue // Same inode number on another filesystem
+		}
+
+		pid, _ := strconv.Atoi(fields[4])
+		if pid > 0 {
+			return pid
+		}
+	}
+
+	return 0
+}
+
+// deviceNumbers splits a device number from stat into its major and
+// minor parts, as glibc encodes them
+func deviceNumbers(dev uint64) (major, minor uint64) {
+	major = (dev>>8)&0xfff | (dev>>32)&^uint64(0xfff)
+	minor = dev&0xff | (dev>>12)&^uint64(0xff)
+	return major, minor
+}
+
+// findProcess returns the first running process with one of names
+func findProcess(names []string) (int, string) {
+	for _, pid := range processIDs() {
+		name := processName(pid)
+		for _, candidate := range names {
+			if name == candidate {
+				return pid, name
+			}
+		}
+	}
+	return 0, ""
+}
+
+// processIDs lists the running processes
+func processIDs() []int {
+	entries, err := os.ReadDir(hostPath("/proc"))
+	if err != nil {
+		return nil
+	}
+
+	pids := []int{}
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+func processExists(pid int) bool {
+	_, err := os.Stat(hostPath(filepath.Join("/proc", strconv.Itoa(pid))))
+	return pid > 0 && err == nil
+}
+
+// processName reads a process's command name, "unknown" if it's gone
+func processName(pid int) string {
+	comm, err := os.ReadFile(hostPath(filepath.Join("/proc", strconv.Itoa(pid), "comm")))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// spinner redraws a single status line while waiting. It only animates
+// on a terminal; otherwise it stays quiet.
+type spinner struct {
+	frame   int
+	visible bool
+	enabled bool
+}
+
+func newSpinner() *spinner {
+	info, err := os.Stdout.Stat()
+	return &spinner{enabled: err == nil && info.Mode()&os.ModeCharDevice != 0}
+}
+
+func (s *spinner) update(message string) {
+	if !s.enabled {
+		return
+	}
+	frames := []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+	s.frame = (s.frame + 1) % len(frames)
+	fmt.Printf("\r\033[K  %c %s", frames[s.frame], message)
+	s.visible = true
+}
+
+func (s *spinner) stop() {
+	if s.visible {
+		fmt.Print("\r\033[K")
+		s.visible = false
+	}
+}
@@ -0,0 +1,305 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeRoot makes system files be read from a temporary directory for the
+// rest of the test and returns it
+func fakeRoot(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	previous := SetRoot(root)
+	t.Cleanup(func() { SetRoot(previous) })
+	return root
+}
+
+// writeFile creates path under root with contents, and its directories
+func writeFile(t *testing.T, root, path, contents string) {
+	t.Helper()
+
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeProcess makes a process with pid and command name appear in /proc
+func fakeProcess(t *testing.T, root string, pid int, name string) {
+	t.Helper()
+	writeFile(t, root, filepath.Join("/proc", strconv.Itoa(pid), "comm"), name+"\n")
+}
+
+// setLockWait changes how waitForLock waits for the rest of the test
+func setLockWait(t *testing.T, timeout time.Duration, noWait bool) {
+	t.Helper()
+
+	lockMu.RLock()
+	previousTimeout, previousNoWait := lockTimeout, lockNoWait
+	lockMu.RUnlock()
+
+	SetLockWait(timeout, noWait)
+	t.Cleanup(func() { SetLockWait(previousTimeout, previousNoWait) })
+}
+
+func TestCheckLockExists(t *testing.T) {
+	pacmanLock := transactionLocks["pacman"][0]
+
+	tests := []struct {
+		name      string
+		lockFile  bool
+		processes map[int]string
+		wantHeld  bool
+		want      LockHolder
+	}{
+		{
+			name:     "no lock file",
+			wantHeld: false,
+		},
+		{
+			name:      "held by pacman",
+			lockFile:  true,
+			processes: map[int]string{88: "bash", 4242: "pacman"},
+			wantHeld:  true,
+			want:      LockHolder{Path: "/var/lib/pacman/db.lck", PID: 4242, Process: "pacman"},
+		},
+		{
+			name:      "held by an AUR helper",
+			lockFile:  true,
+			processes: map[int]string{1337: "paru"},
+			wantHeld:  true,
+			want:      LockHolder{Path: "/var/lib/pacman/db.lck", PID: 1337, Process: "paru"},
+		},
+		{
+			name:      "stale",
+			lockFile:  true,
+			processes: map[int]string{88: "bash"},
+			wantHeld:  true,
+			want:      LockHolder{Path: "/var/lib/pacman/db.lck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fakeRoot(t)
+			if tt.lockFile {
+				writeFile(t, root, pacmanLock.Path, "")
+			}
+			for pid, name := range tt.processes {
+				fakeProcess(t, root, pid, name)
+			}
+
+			holder, held := checkLock(pacmanLock)
+			if held != tt.wantHeld {
+				t.Fatalf("checkLock() held = %v, want %v", held, tt.wantHeld)
+			}
+			if held && *holder != tt.want {
+				t.Errorf("checkLock() = %+v, want %+v", *holder, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLockPIDFile(t *testing.T) {
+	dnfLock := transactionLocks["dnf"][0]
+
+	tests := []struct {
+		name     string
+		contents string
+		running  bool
+		wantHeld bool
+	}{
+		{name: "running", contents: "4242\n", running: true, wantHeld: true},
+		{name: "stale", contents: "4242\n", running: false, wantHeld: false},
+		{name: "empty", contents: "", running: true, wantHeld: false},
+		{name: "garbage", contents: "not a pid", running: true, wantHeld: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fakeRoot(t)
+			writeFile(t, root, dnfLock.Path, tt.contents)
+			if tt.running {
+				fakeProcess(t, root, 4242, "dnf")
+			}
+
+			holder, held := checkLock(dnfLock)
+			if held != tt.wantHeld {
+				t.Fatalf("checkLock() held = %v, want %v", held, tt.wantHeld)
+			}
+			want := LockHolder{Path: dnfLock.Path, PID: 4242, Process: "dnf"}
+			if held && *holder != want {
+				t.Errorf("checkLock() = %+v, want %+v", *holder, want)
+			}
+		})
+	}
+}
+
+func TestCheckLockFcntl(t *testing.T) {
+	apkLock := transactionLocks["apk"][0]
+
+	root := fakeRoot(t)
+	writeFile(t, root, apkLock.Path, "")
+	fakeProcess(t, root, 777, "apk")
+
+	info, err := os.Stat(filepath.Join(root, apkLock.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	major, minor := deviceNumbers(uint64(stat.Dev))
+
+	tests := []struct {
+		name     string
+		locks    string
+		wantHeld bool
+	}{
+		{
+			name:     "unlocked",
+			locks:    "",
+			wantHeld: false,
+		},
+		{
+			name:     "locked",
+			locks:    fmt.Sprintf("1: POSIX  ADVISORY  WRITE 777 %02x:%02x:%d 0 EOF\n", major, minor, stat.Ino),
+			wantHeld: true,
+		},
+		{
+			name:     "same inode on another device",
+			locks:    fmt.Sprintf("1: POSIX  ADVISORY  WRITE 777 %02x:%02x:%d 0 EOF\n", major+1, minor, stat.Ino),
+			wantHeld: false,
+		},
+		{
+			name:     "blocked waiter only",
+			locks:    fmt.Sprintf("1: -> POSIX  ADVISORY  WRITE 777 %02x:%02x:%d 0 EOF\n", major, minor, stat.Ino),
+			wantHeld: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFile(t, root, "/proc/locks", tt.locks)
+
+			holder, held := checkLock(apkLock)
+			if held != tt.wantHeld {
+				t.Fatalf("checkLock() held = %v, want %v", held, tt.wantHeld)
+			}
+			want := LockHolder{Path: apkLock.Path, PID: 777, Process: "apk"}
+			if held && *holder != want {
+				t.Errorf("checkLock() = %+v, want %+v", *holder, want)
+			}
+		})
+	}
+}
+
+func TestDeviceNumbers(t *testing.T) {
+	tests := []struct {
+		dev          uint64
+		major, minor uint64
+	}{
+		{dev: 0x0801, major: 8, minor: 1},
+		{dev: 0xfe00, major: 0xfe, minor: 0},
+		{dev: 0x11032c, major: 259, minor: 300}, // nvme partitions go past minor 255
+	}
+
+	for _, tt := range tests {
+		major, minor := deviceNumbers(tt.dev)
+		if major != tt.major || minor != tt.minor {
+			t.Errorf("deviceNumbers(%#x) = %d:%d, want %d:%d", tt.dev, major, minor, tt.major, tt.minor)
+		}
+	}
+}
+
+func TestWaitForLock(t *testing.T) {
+	const lockPath = "/var/lib/pacman/db.lck"
+
+	tests := []struct {
+		name    string
+		noWait  bool
+		locked  bool
+		holder  bool
+		wantErr error
+	}{
+		{name: "free", locked: false, wantErr: nil},
+		{name: "no wait", noWait: true, locked: true, holder: true, wantErr: ErrDatabaseLocked},
+		{name: "stale lock fails without waiting", locked: true, holder: false, wantErr: ErrDatabaseLocked},
+		{name: "times out", locked: true, holder: true, wantErr: ErrDatabaseLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fakeRoot(t)
+			setLockWait(t, 10*time.Millisecond, tt.noWait)
+			if tt.locked {
+				writeFile(t, root, lockPath, "")
+			}
+			if tt.holder {
+				fakeProcess(t, root, 4242, "pacman")
+			}
+
+			err := waitForLock(context.Background(), "pacman")
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("waitForLock() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitForLockReleased(t *testing.T) {
+	const lockPath = "/var/lib/pacman/db.lck"
+
+	root := fakeRoot(t)
+	setLockWait(t, time.Minute, false)
+	writeFile(t, root, lockPath, "")
+	fakeProcess(t, root, 4242, "pacman")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		os.Remove(filepath.Join(root, lockPath))
+	}()
+
+	if err := waitForLock(context.Background(), "pacman"); err != nil {
+		t.Errorf("waitForLock() = %v, want nil once the lock is gone", err)
+	}
+}
+
+func TestWaitForLockCancelled(t *testing.T) {
+	root := fakeRoot(t)
+	setLockWait(t, time.Minute, false)
+	writeFile(t, root, "/var/lib/pacman/db.lck", "")
+	fakeProcess(t, root, 4242, "pacman")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := waitForLock(ctx, "pacman"); !errors.Is(err, context.Canceled) {
+		t.Errorf("waitForLock() = %v, want %v", err, context.Canceled)
+	}
+}
+
+// holdLock makes a running program hold its own PID file lock for the rest
+// of the test, and makes waiting for it fail straight away
+func holdLock(t *testing.T, program string) {
+	t.Helper()
+
+	root := fakeRoot(t)
+	writeFile(t, root, transactionLocks[program][0].Path, "4242\n")
+	fakeProcess(t, root, 4242, program)
+	setLockWait(t, time.Minute, true)
+
+	if err := waitForLock(context.Background(), program); !errors.Is(err, ErrDatabaseLocked) {
+		t.Fatalf("waitForLock(%q) = %v, want %v", program, err, ErrDatabaseLocked)
+	}
+}
@@ -1,12 +1,51 @@
 // Package pkgmgr provides an abstraction layer for different Linux package managers.
-// It supports DNF (Fedora/RHEL), APT (Ubuntu/Debian), and Pacman (Arch/Manjaro).
+// It supports DNF (Fedora/RHEL), APT (Ubuntu/Debian), Pacman (Arch/Manjaro)
+// Zypper (openSUSE), APK (Alpine) and XBPS (Void).
 package pkgmgr
 
-// PackageManager defines operations all package managers must support
+import (
+	"context"
+	"sort"
+)
+
+// PackageManager defines operations all package managers must support.
+// Every operation stops the commands it runs when ctx is cancelled.
 type PackageManager interface {
-	Install(packages ...string) error
-	Remove(packages ...string) error
-	Update() error
-	Clean() error
-	List() ([]string, error)
+	// Name returns the registry name, e.g. "dnf" or "flatpak"
+	Name() string
+
+	Install(ctx context.Context, packages ...string) error
+	Remove(ctx context.Context, packages ...string) error
+	Update(ctx context.Context) error
+	Clean(ctx context.Context) error
+	List(ctx context.Context) ([]Package, error)
+
+	// Search returns every package whose name or summary matches term
+	Search(ctx context.Context, term string) ([]Package, error)
+	// Info returns details about a single package, installed or not
+	Info(ctx context.Context, name string) (*Package, error)
+	// IsAvailable reports whether a package with exactly this name can be installed
+	IsAvailable(ctx context.Context, name string) bool
+	// IsInstalled reports whether a package with exactly this name is installed
+	IsInstalled(ctx context.Context, name string) bool
+}
+
+// VersionInstaller is implemented by backends that can install a given
+// version of a package, including one older than what is installed. Undo
+// uses it to put back the versions a transaction replaced or removed.
+type VersionInstaller interface {
+	// InstallVersions installs each named package at its version, given
+	// as Package.FullVersion reports it
+	InstallVersions(ctx context.Context, versions map[string]string) error
+}
+
+// versionTargets formats versions as "name<sep>version" arguments, sorted
+// so the command line is stable
+func versionTargets(versions map[string]string, sep string) []string {
+	targets := make([]string, 0, len(versions))
+	for name, version := range versions {
+		targets = append(targets, name+sep+version)
+	}
+	sort.Strings(targets)
+	return targets
 }
@@ -1,9 +1,8 @@
 package pkgmgr
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"strings"
 )
 
@@ -15,7 +14,6 @@ type SourcePreferences struct {
 
 func setupSourcesMenu() SourcePreferences {
 	prefs := SourcePreferences{}
-	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Println("\n🔍 Detecting installed packages...")
 	flatpakInstalled := isFlatpakInstalled()
@@ -63,7 +61,7 @@ func setupSourcesMenu() SourcePreferences {
 	fmt.Println()
 
 	fmt.Print("Install these packages? (Y/n): ")
-	input, _ := reader.ReadString('\n')
+	input, _ := readLine(context.Background())
 	response := strings.TrimSpace(input)
 
 	if response != "y" && response != "Y" && response != "" {
@@ -79,7 +77,7 @@ func setupSourcesMenu() SourcePreferences {
 	}
 	fmt.Print("\nEnter choices (e.g., 1 2 3 or press Enter to install all): ")
 
-	input, _ = reader.ReadString('\n')
+	input, _ = readLine(context.Background())
 	choices := strings.Fields(strings.TrimSpace(input))
 
 	// If empty, select all
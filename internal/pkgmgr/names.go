@@ -0,0 +1,121 @@
+package pkgmgr
+
+import (
+	_ "embed"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// builtinNames is the name table shipped with lazylinux
+//
+//go:embed names.yaml
+var builtinNames []byte
+
+// nameTable maps canonical package names to what each native package
+// manager calls them
+type nameTable map[string]map[string]string
+
+var (
+	nameTableOnce sync.Once
+	loadedNames   nameTable
+)
+
+// UserNamesPath returns the file users extend the name table with
+func UserNamesPath() string {
+	return filepath.Join(filepath.Dir(config.GetConfigPath()), "names.yaml")
+}
+
+// NativeName translates name to what manager calls it. canonical reports
+// whether name was a canonical name, which is always meant to be
+// translated; a name another distribution uses may also be a different
+// package here, so callers should check it before using the translation.
+func NativeName(name, manager string) (native string, canonical bool) {
+	table := loadNames()
+
+	if managers, ok := table[name]; ok {
+		if native, ok := managers[manager]; ok {
+			return native, true
+		}
+		return name, true
+	}
+
+	// Sorted so a name shared by several entries always picks the same one
+	for _, key := range slices.Sorted(maps.Keys(table)) {
+		if !slices.Contains(slices.Collect(maps.Values(table[key])), name) {
+			continue
+		}
+		if native, ok := table[key][manager]; ok {
+			return native, false
+		}
+		return key, false
+	}
+	return name, false
+}
+
+// loadNames reads the built-in table once, with the user's entries merged
+// over it
+func loadNames() nameTable {
+	nameTableOnce.Do(func() {
+		loadedNames = nameTable{}
+		if err := yaml.Unmarshal(builtinNames, &loadedNames); err != nil {
+			panic(fmt.Sprintf("built-in names.yaml is invalid: %v", err))
+		}
+
+		data, err := os.ReadFile(UserNamesPath())
+		if err != nil {
+			return
+		}
+		var user nameTable
+		if err := yaml.Unmarshal(data, &user); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  could not parse %s: %v\n", UserNamesPath(), err)
+			return
+		}
+		for canonical, managers := range user {
+			if loadedNames[canonical] == nil {
+				loadedNames[canonical] = map[string]string{}
+			}
+			maps.Copy(loadedNames[canonical], managers)
+		}
+	})
+	return loadedNames
+}
+
+// nativeCandidate picks the name to install from the native manager:
+// canonical names are translated, other distributions' names only when
+// the name itself isn't a package here. found is the lookup, IsAvailable
+// or IsInstalled.
+func nativeCandidate(packageName, manager string, found func(string) bool) string {
+	native, canonical := NativeName(packageName, manager)
+	if native == packageName || (!canonical && found(packageName)) {
+		return packageName
+	}
+	return native
+}
+
+// removeCandidate picks the name to remove from the native manager. Only
+// canonical names are translated: another distribution's name may be a
+// different package here, which nobody asked to remove.
+func removeCandidate(packageName, manager string) string {
+	if native, canonical := NativeName(packageName, manager); canonical {
+		return native
+	}
+	return packageName
+}
+
+// reportTranslation tells the user when the native manager's sources use
+// a different name than the one they typed
+func reportTranslation(packageName string, sources []PackageSource) {
+	for _, src := range sources {
+		if isNativeSource(src.Manager) && src.Available && src.PackageName != packageName {
+			fmt.Printf("  🔀 %s is called %s on %s\n", packageName, src.PackageName, SourceDisplayName(src.Manager))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// useNames replaces the name table for the rest of the test
+func useNames(t *testing.T, table nameTable) {
+	t.Helper()
+
+	loadNames()
+	previous := loadedNames
+	loadedNames = table
+	t.Cleanup(func() { loadedNames = previous })
+}
+
+// testNames is a slice of names.yaml
+var testNames = nameTable{
+	"fd":      {"apt": "fd-find", "dnf": "fd-find"},
+	"vim":     {"dnf": "vim-enhanced"},
+	"python3": {"pacman": "python"},
+}
+
+func TestNativeName(t *testing.T) {
+	useNames(t, testNames)
+
+	tests := []struct {
+		name, manager string
+		want          string
+		wantCanonical bool
+	}{
+		{"fd", "apt", "fd-find", true},
+		{"fd", "pacman", "fd", true},
+		{"python3", "pacman", "python", true},
+		{"vim-enhanced", "dnf", "vim-enhanced", false},
+		{"vim-enhanced", "apt", "vim", false},
+		{"fd-find", "pacman", "fd", false},
+		{"python", "apt", "python3", false},
+		{"htop", "apt", "htop", false},
+	}
+
+	for _, tt := range tests {
+		native, canonical := NativeName(tt.name, tt.manager)
+		if native != tt.want || canonical != tt.wantCanonical {
+			t.Errorf("NativeName(%q, %q) = %q, %v, want %q, %v", tt.name, tt.manager, native, canonical, tt.want, tt.wantCanonical)
+		}
+	}
+}
+
+func TestNativeCandidate(t *testing.T) {
+	useNames(t, testNames)
+
+	tests := []struct {
+		name     string
+		pkg      string
+		manager  string
+		packages []string // What the manager has
+		want     string
+	}{
+		{name: "canonical", pkg: "fd", manager: "apt", packages: []string{"fd", "fd-find"}, want: "fd-find"},
+		{name: "untranslated", pkg: "htop", manager: "apt", packages: []string{"htop"}, want: "htop"},
+		{name: "foreign name missing here", pkg: "vim-enhanced", manager: "apt", packages: []string{"vim"}, want: "vim"},
+		{name: "foreign name also a package here", pkg: "python", manager: "apt", packages: []string{"python", "python3"}, want: "python"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := func(name string) bool {
+				for _, pkg := range tt.packages {
+					if pkg == name {
+						return true
+					}
+				}
+				return false
+			}
+			if got := nativeCandidate(tt.pkg, tt.manager, found); got != tt.want {
+				t.Errorf("nativeCandidate(%q, %q) = %q, want %q", tt.pkg, tt.manager, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveCandidate(t *testing.T) {
+	useNames(t, testNames)
+
+	tests := []struct {
+		pkg, manager, want string
+	}{
+		{"fd", "apt", "fd-find"},
+		{"python3", "pacman", "python"},
+		{"python", "apt", "python"}, // pacman's python3 is not to be removed
+		{"fd-find", "pacman", "fd-find"},
+	}
+
+	for _, tt := range tests {
+		if got := removeCandidate(tt.pkg, tt.manager); got != tt.want {
+			t.Errorf("removeCandidate(%q, %q) = %q, want %q", tt.pkg, tt.manager, got, tt.want)
+		}
+	}
+}
+
+func TestResolvePackageForRemoveForeignName(t *testing.T) {
+	fake := useFixtures(t, "apt")
+	useNames(t, testNames)
+	fake.Add(
+		Fixture{Args: []string{"dpkg-query", "-W", "-f=${Status}", "python"}, Stderr: "dpkg-query: no packages found matching python\n", ExitCode: 1},
+		Fixture{Args: []string{"dpkg-query", "-W", "-f=${Status}", "python3"}, Stdout: "install ok installed"},
+	)
+
+	sources := ResolvePackageForRemove(context.Background(), "python", NewAPT(), nil)
+
+	want := []PackageSource{{Manager: "apt", PackageName: "python", Available: false, Confidence: 100}}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("ResolvePackageForRemove() = %+v, want %+v", sources, want)
+	}
+	wantCalls := [][]string{{"dpkg-query", "-W", "-f=${Status}", "python"}}
+	if calls := callArgs(fake); !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("ResolvePackageForRemove() ran %q, want %q", calls, wantCalls)
+	}
+}
@@ -0,0 +1,324 @@
+package pkgmgr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// Nix represents a per-user Nix profile
+type Nix struct{}
+
+// NewNix creates a new Nix instance
+func NewNix() *Nix {
+	return &Nix{}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "nix",
+		DisplayName:  "Nix",
+		Icon:         "❄️ ",
+		Priority:     40,
+		Capabilities: CapUserScope,
+		Detect:       func() bool { return isNixInstalled() },
+		Enabled:      func(cfg *config.Config) bool { return cfg.NixEnabled },
+		New:          func(cfg *config.Config) PackageManager { return NewNix() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (n *Nix) Name() string {
+	return "nix"
+}
+
+// nixCommand builds a nix command with flakes enabled, so it works
+// without the user having to edit nix.conf
+func nixCommand(ctx context.Context, args ...string) *Command {
+	base := []string{"--extra-experimental-features", "nix-command flakes"}
+	return newCommand(ctx, "nix", append(base, args...)...)
+}
+
+// nixInstallable turns a package name into a flake reference
+func nixInstallable(pkg string) string {
+	if strings.Contains(pkg, "#") {
+		return pkg
+	}
+	return "nixpkgs#" + pkg
+}
+
+// Install installs packages into the user's profile
+func (n *Nix) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Nix command: nix profile install nixpkgs#<package>...
+	args := []string{"profile", "install"}
+	for _, pkg := range packages {
+		args = append(args, nixInstallable(pkg))
+	}
+	cmd := nixCommand(ctx, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Remove removes packages from the user's profile
+func (n *Nix) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Nix command: nix profile remove <names>
+	args := []string{"profile", "remove"}
+	for _, pkg := range packages {
+		args = append(args, strings.TrimPrefix(pkg, "nixpkgs#"))
+	}
+	cmd := nixCommand(ctx, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Update upgrades every package in the user's profile
+func (n *Nix) Update(ctx context.Context) error {
+	// Nix command: nix profile upgrade --all. Before 2.20 elements were
+	// matched by regex instead: nix profile upgrade '.*'
+	target := "--all"
+	if version := nixVersion(ctx); version != "" && compareVersions(version, "2.20") < 0 {
+		target = ".*"
+	}
+
+	cmd := nixCommand(ctx, "profile", "upgrade", target)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// nixVersion returns the installed Nix version, e.g. "2.24.9", or "" if
+// it can't be read. "nix --version" prints "nix (Nix) 2.24.9".
+func nixVersion(ctx context.Context) string {
+	output, err := newCommand(ctx, "nix", "--version").Output()
+	if err != nil {
+		return ""
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// Clean deletes old profile generations and garbage collects the store
+func (n *Nix) Clean(ctx context.Context) error {
+	fmt.Println("🧹 Collecting Nix garbage...")
+	cmd := newCommand(ctx, "nix-collect-garbage", "-d")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// List lists all packages in the user's profile
+func (n *Nix) List(ctx context.Context) ([]Package, error) {
+	cmd := nixCommand(ctx, "profile", "list", "--json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNixProfile(output)
+}
+
+// nixProfileElement is one entry of "nix profile list --json"
+type nixProfileElement struct {
+	AttrPath    string   `json:"attrPath"`
+	OriginalURL string   `json:"originalUrl"`
+	StorePaths  []string `json:"storePaths"`
+}
+
+// parseNixProfile reads "nix profile list --json". Nix 2.20 and later key
+// elements by name, older versions return an array.
+func parseNixProfile(data []byte) ([]Package, error) {
+	var profile struct {
+		Elements json.RawMessage `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("could not parse nix profile: %v", err)
+	}
+
+	var results []Package
+
+	var named map[string]nixProfileElement
+	if err := json.Unmarshal(profile.Elements, &named); err == nil {
+		names := make([]string, 0, len(named))
+		for name := range named {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			results = append(results, nixPackage(name, named[name]))
+		}
+		return results, nil
+	}
+
+	var indexed []nixProfileElement
+	if err := json.Unmarshal(profile.Elements, &indexed); err != nil {
+		return nil, fmt.Errorf("could not parse nix profile: %v", err)
+	}
+
+	for _, element := range indexed {
+		// attrPath looks like "legacyPackages.x86_64-linux.ripgrep"
+		parts := strings.Split(element.AttrPath, ".")
+		name := parts[len(parts)-1]
+		if name != "" {
+			results = append(results, nixPackage(name, element))
+		}
+	}
+
+	return results, nil
+}
+
+// nixPackage builds a Package from a profile element. The version is read
+// from the store path: /nix/store/<hash>-ripgrep-14.1.0
+func nixPackage(name string, element nixProfileElement) Package {
+	pkg := Package{
+		Name:       name,
+		Repository: element.OriginalURL,
+		Source:     "nix",
+		Reason:     ReasonExplicit,
+	}
+
+	// attrPath: "legacyPackages.<system>.<name>"
+	if parts := strings.Split(element.AttrPath, "."); len(parts) >= 3 {
+		pkg.Arch = parts[1]
+	}
+
+	if len(element.StorePaths) > 0 {
+		base := element.StorePaths[0][strings.LastIndex(element.StorePaths[0], "/")+1:]
+		if _, nameVersion, found := strings.Cut(base, "-"); found {
+			if i := strings.LastIndex(nameVersion, "-"); i > 0 {
+				version := nameVersion[i+1:]
+				if version != "" && version[0] >= '0' && version[0] <= '9' {
+					pkg.Version = version
+				}
+			}
+		}
+	}
+
+	return pkg
+}
+
+// isNixInstalled checks if nix is available
+func isNixInstalled() bool {
+	return commandExists("nix")
+}
+
+// Search searches nixpkgs for term. This evaluates all of nixpkgs and can
+// take a while the first time.
+func (n *Nix) Search(ctx context.Context, term string) ([]Package, error) {
+	cmd := nixCommand(ctx, "search", "nixpkgs", term, "--json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nixpkgs: %w", err)
+	}
+
+	// {"legacyPackages.x86_64-linux.ripgrep": {"pname": "ripgrep", "version": "14.1.0", "description": "..."}}
+	var found map[string]struct {
+		Pname       string `json:"pname"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(output, &found); err != nil {
+		return nil, fmt.Errorf("could not parse nix search output: %v", err)
+	}
+
+	attrPaths := make([]string, 0, len(found))
+	for attrPath := range found {
+		attrPaths = append(attrPaths, attrPath)
+	}
+	sort.Strings(attrPaths)
+
+	var results []Package
+	for _, attrPath := range attrPaths {
+		entry := found[attrPath]
+		parts := strings.SplitN(attrPath, ".", 3)
+		if len(parts) < 3 {
+			continue
+		}
+
+		results = append(results, Package{
+			Name:        parts[2], // Attribute name, what "nixpkgs#<name>" expects
+			DisplayName: entry.Pname,
+			Summary:     entry.Description,
+			Version:     entry.Version,
+			Arch:        parts[1],
+			Repository:  "nixpkgs",
+			Source:      "nix",
+		})
+	}
+
+	return results, nil
+}
+
+// Info returns details for a nixpkgs attribute
+func (n *Nix) Info(ctx context.Context, name string) (*Package, error) {
+	installable := nixInstallable(name)
+	cmd := nixCommand(ctx, "eval", "--json", installable, "--apply",
+		`p: { pname = p.pname or p.name; version = p.version or ""; description = p.meta.description or ""; homepage = p.meta.homepage or ""; }`)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w in nixpkgs: %s", ErrPackageNotFound, name)
+	}
+
+	var meta struct {
+		Pname       string `json:"pname"`
+		Version     string `json:"version"`
+		Description string `json:"description"`
+		Homepage    string `json:"homepage"`
+	}
+	if err := json.Unmarshal(output, &meta); err != nil {
+		return nil, fmt.Errorf("could not parse nix eval output: %v", err)
+	}
+
+	name = strings.TrimPrefix(installable, "nixpkgs#")
+	return &Package{
+		Name:        name,
+		DisplayName: meta.Pname,
+		Summary:     meta.Description,
+		URL:         meta.Homepage,
+		Version:     meta.Version,
+		Repository:  "nixpkgs",
+		Source:      "nix",
+		Installed:   n.IsInstalled(ctx, name),
+	}, nil
+}
+
+// IsAvailable checks whether nixpkgs has an attribute with this name
+func (n *Nix) IsAvailable(ctx context.Context, name string) bool {
+	cmd := nixCommand(ctx, "eval", "--raw", nixInstallable(name)+".name")
+	output, err := cmd.Output()
+	return err == nil && len(output) > 0
+}
+
+// IsInstalled checks whether a package is in the user's profile
+func (n *Nix) IsInstalled(ctx context.Context, name string) bool {
+	packages, err := n.List(ctx)
+	if err != nil {
+		return false
+	}
+	name = strings.TrimPrefix(name, "nixpkgs#")
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNixUpdate(t *testing.T) {
+	nix := func(args ...string) []string {
+		return append([]string{"nix", "--extra-experimental-features", "nix-command flakes"}, args...)
+	}
+
+	tests := []struct {
+		name    string
+		version Fixture
+		want    []string
+	}{
+		{
+			name:    "nix 2.24",
+			version: Fixture{Args: []string{"nix", "--version"}, Stdout: "nix (Nix) 2.24.9\n"},
+			want:    nix("profile", "upgrade", "--all"),
+		},
+		{
+			name:    "nix 2.20",
+			version: Fixture{Args: []string{"nix", "--version"}, Stdout: "nix (Nix) 2.20.0\n"},
+			want:    nix("profile", "upgrade", "--all"),
+		},
+		{
+			name:    "nix 2.18 matches by regex",
+			version: Fixture{Args: []string{"nix", "--version"}, Stdout: "nix (Nix) 2.18.1\n"},
+			want:    nix("profile", "upgrade", ".*"),
+		},
+		{
+			name:    "unknown version",
+			version: Fixture{Args: []string{"nix", "--version"}, ExitCode: 1},
+			want:    nix("profile", "upgrade", "--all"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFixtures(t)
+			fake.Add(tt.version, Fixture{Args: tt.want})
+
+			if err := NewNix().Update(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			calls := callArgs(fake)
+			if got := calls[len(calls)-1]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Update() ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNixList(t *testing.T) {
+	useFixtures(t, "nix")
+
+	packages, err := NewNix().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "fd", Version: "10.2.0", Arch: "x86_64-linux", Repository: "flake:nixpkgs", Source: "nix", Reason: ReasonExplicit},
+		{Name: "ripgrep", Version: "14.1.1", Arch: "x86_64-linux", Repository: "flake:nixpkgs", Source: "nix", Reason: ReasonExplicit},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestParseNixProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Package
+		wantErr bool
+	}{
+		{
+			name: "nix 2.20 and later",
+			data: `{"elements":{"htop":{"attrPath":"legacyPackages.aarch64-linux.htop","originalUrl":"flake:nixpkgs","storePaths":["/nix/store/0f1r-htop-3.3.0"]}},"version":3}`,
+			want: []Package{
+				{Name: "htop", Version: "3.3.0", Arch: "aarch64-linux", Repository: "flake:nixpkgs", Source: "nix", Reason: ReasonExplicit},
+			},
+		},
+		{
+			name: "before nix 2.20",
+			data: `{"elements":[{"active":true,"attrPath":"legacyPackages.x86_64-linux.htop","originalUrl":"flake:nixpkgs","storePaths":["/nix/store/0f1r-htop-3.3.0"]}],"version":2}`,
+			want: []Package{
+				{Name: "htop", Version: "3.3.0", Arch: "x86_64-linux", Repository: "flake:nixpkgs", Source: "nix", Reason: ReasonExplicit},
+			},
+		},
+		{
+			name: "version-less store path",
+			data: `{"elements":{"hello-unstable":{"attrPath":"packages.x86_64-linux.default","originalUrl":"github:me/hello","storePaths":["/nix/store/0f1r-hello-unstable"]}},"version":3}`,
+			want: []Package{
+				{Name: "hello-unstable", Arch: "x86_64-linux", Repository: "github:me/hello", Source: "nix", Reason: ReasonExplicit},
+			},
+		},
+		{
+			name: "empty profile",
+			data: `{"elements":{},"version":3}`,
+			want: nil,
+		},
+		{
+			name:    "not json",
+			data:    "error: profile not found",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNixProfile([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNixProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNixProfile() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNixSearch(t *testing.T) {
+	useFixtures(t, "nix")
+
+	packages, err := NewNix().Search(context.Background(), "ripgrep")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "ripgrep", DisplayName: "ripgrep", Summary: "Utility that combines the usability of The Silver Searcher with the raw speed of grep", Version: "14.1.1", Arch: "x86_64-linux", Repository: "nixpkgs", Source: "nix"},
+		{Name: "ripgrep-all", DisplayName: "ripgrep-all", Summary: "Ripgrep, but also search in PDFs, E-Books, Office documents, zip, tar.gz, and more", Version: "0.10.6", Arch: "x86_64-linux", Repository: "nixpkgs", Source: "nix"},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("Search() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestNixInfo(t *testing.T) {
+	useFixtures(t, "nix")
+
+	pkg, err := NewNix().Info(context.Background(), "ripgrep")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Package{
+		Name:        "ripgrep",
+		DisplayName: "ripgrep",
+		Summary:     "Utility that combines the usability of The Silver Searcher with the raw speed of grep",
+		URL:         "https://github.com/BurntSushi/ripgrep",
+		Version:     "14.1.1",
+		Repository:  "nixpkgs",
+		Source:      "nix",
+		Installed:   true,
+	}
+	if !reflect.DeepEqual(pkg, want) {
+		t.Errorf("Info() =\n%+v\nwant\n%+v", pkg, want)
+	}
+
+	if _, err := NewNix().Info(context.Background(), "ripgerp"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("Info(%q) = %v, want %v", "ripgerp", err, ErrPackageNotFound)
+	}
+}
@@ -0,0 +1,238 @@
+package pkgmgr
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InstallReason records why a package is on the system
+type InstallReason string
+
+const (
+	ReasonUnknown    InstallReason = ""
+	ReasonExplicit   InstallReason = "explicit"   // Installed on request
+	ReasonDependency InstallReason = "dependency" // Pulled in by another package
+)
+
+// Package describes a package as reported by its manager, either
+// installed (List, Info) or available (Search, Info)
+type Package struct {
+	Name          string        `json:"name" yaml:"name"`
+	DisplayName   string        `json:"display_name,omitempty" yaml:"display_name,omitempty"` // Human readable name (Flatpak apps)
+	Summary       string        `json:"summary,omitempty" yaml:"summary,omitempty"`
+	URL           string        `json:"url,omitempty" yaml:"url,omitempty"`
+	Version       string        `json:"version,omitempty" yaml:"version,omitempty"`
+	Release       string        `json:"release,omitempty" yaml:"release,omitempty"` // Packaging revision (rpm release, pkgrel, apk -rN)
+	Arch          string        `json:"arch,omitempty" yaml:"arch,omitempty"`
+	Repository    string        `json:"repository,omitempty" yaml:"repository,omitempty"` // Repo, remote/branch, snap channel or origin
+	Source        string        `json:"source" yaml:"source"`                             // Manager that owns it: "dnf", "flatpak", ...
+	InstalledSize int64         `json:"installed_size,omitempty" yaml:"installed_size,omitempty"`
+	Reason        InstallReason `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Installed     bool          `json:"installed,omitempty" yaml:"installed,omitempty"` // Set by Search and Info
+}
+
+// FullVersion returns "version-release", or just the version without a release
+func (p Package) FullVersion() string {
+	if p.Release == "" {
+		return p.Version
+	}
+	return p.Version + "-" + p.Release
+}
+
+// String formats the package for listings, e.g. "vim 9.1.0-1.fc40 (updates)"
+func (p Package) String() string {
+	s := p.Name
+	if v := p.FullVersion(); v != "" {
+		s += " " + v
+	}
+	if p.Repository != "" {
+		s += " (" + p.Repository + ")"
+	}
+	return s
+}
+
+// splitVersionRelease splits "1.2.3-4" at the last dash
+func splitVersionRelease(evr string) (string, string) {
+	i := strings.LastIndex(evr, "-")
+	if i <= 0 {
+		return evr, ""
+	}
+	return evr[:i], evr[i+1:]
+}
+
+// compareVersions orders two version strings the way rpm and dpkg do,
+// returning -1, 0 or 1. An "epoch:" prefix wins over everything else,
+// digit runs compare as numbers and "~" sorts before anything, so
+// "1.0~rc1" comes before "1.0".
+func compareVersions(a, b string) int {
+	epochA, restA := splitEpoch(a)
+	epochB, restB := splitEpoch(b)
+	if epochA != epochB {
+		return cmp.Compare(epochA, epochB)
+	}
+	a, b = restA, restB
+
+	isAlnum := func(c byte) bool {
+		return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+
+	for a != "" || b != "" {
+		// Separators only split segments
+		for a != "" && !isAlnum(a[0]) && a[0] != '~' {
+			a = a[1:]
+		}
+		for b != "" && !isAlnum(b[0]) && b[0] != '~' {
+			b = b[1:]
+		}
+
+		// A tilde makes a version older, even than the end of the other
+		tildeA, tildeB := strings.HasPrefix(a, "~"), strings.HasPrefix(b, "~")
+		if tildeA || tildeB {
+			if tildeA && tildeB {
+				a, b = a[1:], b[1:]
+				continue
+			}
+			if tildeA {
+				return -1
+			}
+			return 1
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		// Compare the next run of digits or letters
+		numeric := isDigit(a[0])
+		if numeric != isDigit(b[0]) {
+			if numeric {
+				return 1 // Numbers are newer than letters
+			}
+			return -1
+		}
+
+		sameKind := func(c byte) bool { return isAlnum(c) && isDigit(c) == numeric }
+		endA, endB := 0, 0
+		for endA < len(a) && sameKind(a[endA]) {
+			endA++
+		}
+		for endB < len(b) && sameKind(b[endB]) {
+			endB++
+		}
+		segA, segB := a[:endA], b[:endB]
+		a, b = a[endA:], b[endB:]
+
+		if numeric {
+			segA, segB = strings.TrimLeft(segA, "0"), strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				return cmp.Compare(len(segA), len(segB))
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+	}
+
+	// Whichever has segments left is newer
+	return cmp.Compare(len(a), len(b))
+}
+
+// splitEpoch splits "2:1.0" into 2 and "1.0"; versions without one have
+// epoch 0
+func splitEpoch(version string) (int, string) {
+	before, after, found := strings.Cut(version, ":")
+	if !found {
+		return 0, version
+	}
+	epoch, err := strconv.Atoi(before)
+	if err != nil {
+		return 0, version
+	}
+	return epoch, after
+}
+
+// parseHumanSize converts sizes such as "1.5 MiB", "12,3 MB", "523 kB"
+// or "5MB" into bytes. Unknown formats return 0.
+func parseHumanSize(size string) int64 {
+	size = strings.TrimSpace(strings.ReplaceAll(size, ",", "."))
+	end := strings.IndexFunc(size, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end == -1 {
+		end = len(size)
+	}
+
+	value, err := strconv.ParseFloat(size[:end], 64)
+	if err != nil {
+		return 0
+	}
+
+	unit := strings.TrimSpace(size[end:])
+	if unit == "" {
+		unit = "B"
+	}
+
+	multipliers := map[string]float64{
+		"B":   1,
+		"kB":  1e3,
+		"KB":  1e3,
+		"MB":  1e6,
+		"GB":  1e9,
+		"TB":  1e12,
+		"k":   1 << 10, // dnf prints "20 k" and "2.0 M"
+		"M":   1 << 20,
+		"G":   1 << 30,
+		"KiB": 1 << 10,
+		"MiB": 1 << 20,
+		"GiB": 1 << 30,
+		"TiB": 1 << 40,
+	}
+
+	multiplier, ok := multipliers[unit]
+	if !ok {
+		return 0
+	}
+	return int64(value * multiplier)
+}
+
+// FormatSize renders a byte count for humans, e.g. "12.4 MiB"
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// parseKeyValue parses "Key : Value" output as printed by pacman -Si,
+// zypper info, flatpak info and similar tools. Only the first occurrence
+// of a key is kept and continuation lines are ignored.
+func parseKeyValue(output string) map[string]string {
+	fields := map[string]string{}
+	lines := strings.SplitSeq(output, "\n")
+
+	for line := range lines {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" || strings.Contains(key, "  ") {
+			continue
+		}
+		if _, seen := fields[key]; !seen {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return fields
+}
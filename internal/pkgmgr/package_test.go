@@ -0,0 +1,165 @@
+package pkgmgr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.01", "1.1", 0},
+		{"9.1.0016", "9.1.16", 0},
+		{"1.0.1", "1.0", 1},
+
+		// Epochs win over everything else
+		{"2:1.0", "1:9.9", 1},
+		{"1:1.0", "1.0", 1},
+		{"0:1.0", "1.0", 0},
+		{"1:0.1", "2.0", 1},
+
+		// Tildes sort before anything, even the end of the version
+		{"1.0~rc1", "1.0", -1},
+		{"1.0~rc1", "1.0~rc2", -1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0", "1.0~", 1},
+
+		// Letters and digits compare by kind, then within their kind
+		{"1.0a", "1.0", 1},
+		{"1.0a", "1.0.1", -1},
+		{"1.0a", "1.0b", -1},
+		{"2.4.0-1ubuntu2", "2.4.0-1ubuntu10", -1},
+		{"8.6.0-10.fc40", "8.6.0-8.fc40", 1},
+		{"3.11.9-1.fc40", "3.11.9-1.fc40", 0},
+		{"r1000", "r999", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestSplitVersionRelease(t *testing.T) {
+	tests := []struct {
+		evr, version, release string
+	}{
+		{"1.2.3-4", "1.2.3", "4"},
+		{"9.1.393-1.fc40", "9.1.393", "1.fc40"},
+		{"1.0.1-a.12-2", "1.0.1-a.12", "2"},
+		{"1.0", "1.0", ""},
+		{"-1", "-1", ""},
+	}
+
+	for _, tt := range tests {
+		version, release := splitVersionRelease(tt.evr)
+		if version != tt.version || release != tt.release {
+			t.Errorf("splitVersionRelease(%q) = %q, %q, want %q, %q", tt.evr, version, release, tt.version, tt.release)
+		}
+	}
+}
+
+func TestParseHumanSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"100", 100},
+		{"523 kB", 523_000},
+		{"5MB", 5_000_000},
+		{"12,3 MB", 12_300_000},
+		{"1.5 MiB", 3 << 19},
+		{"2 GiB", 2 << 30},
+		{"20 k", 20 << 10},
+		{"2.0 M", 2 << 20},
+		{" 274.6 MB ", 274_600_000},
+		{"", 0},
+		{"unknown", 0},
+		{"2 XB", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseHumanSize(tt.size); got != tt.want {
+			t.Errorf("parseHumanSize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{3 << 19, "1.5 MiB"},
+		{5 << 30, "5.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.bytes); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeyValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]string
+	}{
+		{
+			name: "pacman -Si",
+			output: `Repository      : extra
+Name            : vim
+Version         : 9.1.0785-1
+Description     : Vi Improved, a highly configurable, improved version of the vi text editor
+URL             : https://www.vim.org
+Depends On      : vim-runtime=9.1.0785-1  gpm  acl  glibc  libgcrypt  pcre
+                  zlib
+`,
+			want: map[string]string{
+				"Repository":  "extra",
+				"Name":        "vim",
+				"Version":     "9.1.0785-1",
+				"Description": "Vi Improved, a highly configurable, improved version of the vi text editor",
+				"URL":         "https://www.vim.org",
+				"Depends On":  "vim-runtime=9.1.0785-1  gpm  acl  glibc  libgcrypt  pcre",
+			},
+		},
+		{
+			name:   "first occurrence wins",
+			output: "Version: 2.0\nVersion: 1.0\n",
+			want:   map[string]string{"Version": "2.0"},
+		},
+		{
+			name:   "table rows are skipped",
+			output: "name:  firefox\nlatest/stable   129.0  mozilla:  x\n",
+			want:   map[string]string{"name": "firefox"},
+		},
+		{
+			name:   "empty",
+			output: "",
+			want:   map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKeyValue(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKeyValue() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
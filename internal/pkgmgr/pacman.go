@@ -1,10 +1,15 @@
 package pkgmgr
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
 )
 
 type Pacman struct{}
@@ -13,45 +18,109 @@ func NewPacman() *Pacman {
 	return &Pacman{}
 }
 
-func (p *Pacman) Install(packages ...string) error {
+func init() {
+	Register(Backend{
+		Name:         "pacman",
+		DisplayName:  "Pacman",
+		Icon:         "📦",
+		Native:       true,
+		Priority:     30,
+		Capabilities: CapSearch | CapOrphans | CapInstallReason,
+		Detect:       func() bool { return commandExists("pacman") },
+		New:          func(cfg *config.Config) PackageManager { return NewPacman() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (p *Pacman) Name() string {
+	return "pacman"
+}
+
+func (p *Pacman) Install(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 
 	// Pacman command: sudo pacman -S --noconfirm <packages>
 	args := append([]string{"-S", "--noconfirm"}, packages...)
-	cmd := exec.Command("sudo", append([]string{"pacman"}, args...)...)
+	cmd := privilegedCommand(ctx, "pacman", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// pacmanCacheDir holds every package version pacman has downloaded
+const pacmanCacheDir = "/var/cache/pacman/pkg"
+
+// InstallVersions reinstalls versions from the package cache, since the
+// sync databases only carry the latest one
+func (p *Pacman) InstallVersions(ctx context.Context, versions map[string]string) error {
+	var files []string
+	for name, version := range versions {
+		file, err := cachedPackage(name, version)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	// Pacman command: sudo pacman -U --noconfirm <files>
+	cmd := privilegedCommand(ctx, "pacman", append([]string{"-U", "--noconfirm"}, files...)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (p *Pacman) Remove(packages ...string) error {
+// cachedPackage finds the cached file for name at version, which is named
+// "<name>-[epoch:]<version>-<arch>.pkg.tar.<ext>"
+func cachedPackage(name, version string) (string, error) {
+	matches, _ := filepath.Glob(filepath.Join(hostPath(pacmanCacheDir), name+"-*.pkg.tar.*"))
+	for _, match := range matches {
+		if strings.HasSuffix(match, ".sig") {
+			continue
+		}
+
+		base := filepath.Base(match)
+		rest := strings.TrimPrefix(base, name+"-")
+		rest = rest[:strings.Index(rest, ".pkg.tar.")]
+		i := strings.LastIndex(rest, "-") // Architecture
+		if i < 0 {
+			continue
+		}
+		if _, bare := splitEpoch(rest[:i]); bare == version {
+			return filepath.Join(pacmanCacheDir, base), nil
+		}
+	}
+	return "", fmt.Errorf("%s %s is no longer in the package cache", name, version)
+}
+
+func (p *Pacman) Remove(ctx context.Context, packages ...string) error {
 	if len(packages) == 0 {
 		return fmt.Errorf("no packages specified")
 	}
 
 	// Pacman command: sudo pacman -R --noconfirm <packages>
 	args := append([]string{"-R", "--noconfirm"}, packages...)
-	cmd := exec.Command("sudo", append([]string{"pacman"}, args...)...)
+	cmd := privilegedCommand(ctx, "pacman", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (p *Pacman) Update() error {
+func (p *Pacman) Update(ctx context.Context) error {
 	// Pacman command: sudo pacman -Syu --noconfirm
 	// -S = sync, -y = refresh repos, -u = upgrade
-	cmd := exec.Command("sudo", "pacman", "-Syu", "--noconfirm")
+	cmd := privilegedCommand(ctx, "pacman", "-Syu", "--noconfirm")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func (p *Pacman) Clean() error {
+func (p *Pacman) Clean(ctx context.Context) error {
 	// Clean package cache (keep only current versions)
 	fmt.Println("🧹 Cleaning package cache...")
-	cleanCmd := exec.Command("sudo", "pacman", "-Sc", "--noconfirm")
+	cleanCmd := privilegedCommand(ctx, "pacman", "-Sc", "--noconfirm")
 	cleanCmd.Stdout = os.Stdout
 	cleanCmd.Stderr = os.Stderr
 	err := cleanCmd.Run()
@@ -63,44 +132,270 @@ func (p *Pacman) Clean() error {
 	fmt.Println("🗑️  Removing orphaned packages...")
 
 	// First check if there are orphaned packages
-	checkCmd := exec.Command("pacman", "-Qtdq")
+	checkCmd := newCommand(ctx, "pacman", "-Qtdq")
 	output, err := checkCmd.Output()
 	if err != nil || len(output) == 0 {
 		fmt.Println("✨ No orphaned packages found")
 		return nil
 	}
 
-	// Remove orphaned packages
-	removeCmd := exec.Command("sudo", "pacman", "-Rns", "--noconfirm", string(output))
+	// Remove orphaned packages (one name per line)
+	args := append([]string{"-Rns", "--noconfirm"}, strings.Fields(string(output))...)
+	removeCmd := privilegedCommand(ctx, "pacman", args...)
 	removeCmd.Stdout = os.Stdout
 	removeCmd.Stderr = os.Stderr
 	return removeCmd.Run()
 }
 
+// pacmanPrintFormat makes --print list one "name version size" target
+// per line instead of running the transaction
+const pacmanPrintFormat = "%n %v %s"
+
+// Simulate previews an operation with pacman's --print, which resolves
+// the transaction without root. Updates use the sync databases as they
+// are, without refreshing them first.
+func (p *Pacman) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	var args []string
+	action := ActionInstall
+	switch op {
+	case OpInstall:
+		args = append([]string{"-S"}, packages...)
+	case OpRemove:
+		args = append([]string{"-R"}, packages...)
+		action = ActionRemove
+	case OpUpdate:
+		args = []string{"-Su"}
+	case OpClean:
+		// Only the orphans change; the cache isn't a package
+		orphans, _ := newCommand(ctx, "pacman", "-Qtdq").Output()
+		if len(orphans) == 0 {
+			return &ChangeSet{}, nil
+		}
+		args = append([]string{"-Rns"}, strings.Fields(string(orphans))...)
+		action = ActionRemove
+	}
+
+	cmd := newCommand(ctx, "pacman", append(args, "--print", "--print-format", pacmanPrintFormat)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	set := parsePacmanPrint(string(output), action)
+	if action == ActionInstall {
+		// Sync targets include upgrades; only the installed versions tell
+		if err := markUpgrades(ctx, p, set); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// parsePacmanPrint parses targets printed with pacmanPrintFormat
+func parsePacmanPrint(output string, action ChangeAction) *ChangeSet {
+	set := &ChangeSet{}
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue // Warnings and prompts
+		}
+
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		set.Add(action, fields[0], fields[1], size)
+		if action != ActionRemove {
+			set.DownloadSize += size
+		}
+	}
+
+	return set
+}
+
 // List lists all installed packages
-func (p *Pacman) List() ([]string, error) {
-	// pacman -Q (list all installed)
-	cmd := exec.Command("pacman", "-Q")
+func (p *Pacman) List(ctx context.Context) ([]Package, error) {
+	// pacman -Qi (detailed info for all installed packages)
+	cmd := newCommand(ctx, "pacman", "-Qi")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePacmanInfo(string(output), "pacman"), nil
+}
+
+// parsePacmanInfo parses "pacman -Qi" output, which is a series of
+// "Key : Value" blocks separated by blank lines
+func parsePacmanInfo(output, source string) []Package {
+	var results []Package
+	current := Package{Source: source}
+
+	flush := func() {
+		if current.Name != "" {
+			results = append(results, current)
+		}
+		current = Package{Source: source}
+	}
+
+	lines := strings.SplitSeq(output, "\n")
+	for line := range lines {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Name":
+			current.Name = value
+		case "Version":
+			// Drop the epoch, "1:2.0-3" → "2.0-3"
+			if i := strings.Index(value, ":"); i >= 0 {
+				value = value[i+1:]
+			}
+			current.Version, current.Release = splitVersionRelease(value)
+		case "Description":
+			current.Summary = value
+		case "URL":
+			current.URL = value
+		case "Architecture":
+			current.Arch = value
+		case "Repository":
+			current.Repository = value
+		case "Installed Size":
+			current.InstalledSize = parseHumanSize(value)
+		case "Install Reason":
+			if strings.HasPrefix(value, "Explicitly") {
+				current.Reason = ReasonExplicit
+			} else {
+				current.Reason = ReasonDependency
+			}
+		}
+	}
+	flush()
+
+	return results
+}
+
+// Search searches the sync databases for term
+func (p *Pacman) Search(ctx context.Context, term string) ([]Package, error) {
+	// pacman -Ss <term>
+	cmd := newCommand(ctx, "pacman", "-Ss", term)
 	output, err := cmd.Output()
 	if err != nil {
+		// pacman -Ss exits 1 when nothing matches
+		if len(output) == 0 {
+			return nil, nil
+		}
 		return nil, err
 	}
 
-	// Parse output
-	var results []string
-	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+	return parsePacmanSearch(string(output), "pacman"), nil
+}
+
+// Index lists every package in the sync databases, which pacman -Ss
+// does when given no term
+func (p *Pacman) Index(ctx context.Context) ([]Package, error) {
+	output, err := newCommand(ctx, "pacman", "-Ss").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePacmanSearch(string(output), "pacman"), nil
+}
+
+// Info returns details for a package from the sync databases, falling
+// back to the local database for packages not in any repository
+func (p *Pacman) Info(ctx context.Context, name string) (*Package, error) {
+	output, err := newCommand(ctx, "pacman", "-Si", name).Output()
+	if err != nil {
+		output, err = newCommand(ctx, "pacman", "-Qi", name).Output()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+		}
+	}
+
+	packages := parsePacmanInfo(string(output), "pacman")
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	pkg := packages[0]
+	pkg.Installed = p.IsInstalled(ctx, name)
+	return &pkg, nil
+}
+
+// IsAvailable checks the sync databases for an exact package name
+func (p *Pacman) IsAvailable(ctx context.Context, name string) bool {
+	// pacman -Ss <package>
+	cmd := newCommand(ctx, "pacman", "-Ss", "^"+name+"$")
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// IsInstalled checks the local database for a package
+func (p *Pacman) IsInstalled(ctx context.Context, name string) bool {
+	// pacman -Q <package> (check installed)
+	cmd := newCommand(ctx, "pacman", "-Q", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// parsePacmanSearch parses "pacman -Ss" style output, which AUR helpers
+// share:
+//
+//	extra/vim 9.1.0-1 [installed]
+//	    Vi Improved, a highly configurable, improved version of the vi text editor
+func parsePacmanSearch(output, source string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(output, "\n")
 
 	for line := range lines {
-		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
-		// pacman -Q output: "package-name version"
-		parts := strings.Fields(line)
-		if len(parts) >= 1 {
-			results = append(results, parts[0])
+
+		// Descriptions are indented and belong to the previous package
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if len(results) > 0 && results[len(results)-1].Summary == "" {
+				results[len(results)-1].Summary = strings.TrimSpace(line)
+			}
+			continue
 		}
+
+		fields := strings.Fields(line)
+		repo, name, found := strings.Cut(fields[0], "/")
+		if !found {
+			continue
+		}
+
+		pkg := Package{
+			Name:       name,
+			Repository: repo,
+			Source:     source,
+			Installed:  strings.Contains(strings.ToLower(line), "installed"),
+		}
+		if len(fields) >= 2 {
+			pkg.Version, pkg.Release = splitVersionRelease(fields[1])
+		}
+
+		results = append(results, pkg)
 	}
 
-	return results, nil
+	return results
 }
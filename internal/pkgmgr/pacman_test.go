@@ -0,0 +1,120 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPacmanClean(t *testing.T) {
+	fake := useFixtures(t, "pacman")
+
+	if err := NewPacman().Clean(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"sudo", "pacman", "-Sc", "--noconfirm"},
+		{"pacman", "-Qtdq"},
+		{"sudo", "pacman", "-Rns", "--noconfirm", "gnome-common", "python-setuptools-scm"},
+	}
+	if got := callArgs(fake); !reflect.DeepEqual(got, want) {
+		t.Errorf("Clean() ran\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPacmanCleanWithoutOrphans(t *testing.T) {
+	tests := []struct {
+		name   string
+		orphan Fixture
+	}{
+		{
+			name:   "no output",
+			orphan: Fixture{Args: []string{"pacman", "-Qtdq"}},
+		},
+		{
+			// pacman exits 1 when no package matches the query
+			name:   "exit status 1",
+			orphan: Fixture{Args: []string{"pacman", "-Qtdq"}, ExitCode: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFixtures(t)
+			fake.Add(Fixture{Args: []string{"sudo", "pacman", "-Sc", "--noconfirm"}}, tt.orphan)
+
+			if err := NewPacman().Clean(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if calls := callArgs(fake); len(calls) != 2 {
+				t.Errorf("Clean() ran %q, want no removal", calls)
+			}
+		})
+	}
+}
+
+func TestPacmanCleanCacheFailure(t *testing.T) {
+	fake := useFixtures(t)
+	fake.Add(Fixture{
+		Args:     []string{"sudo", "pacman", "-Sc", "--noconfirm"},
+		Stderr:   "error: failed to init transaction (unable to lock database)\n",
+		ExitCode: 1,
+	})
+
+	if err := NewPacman().Clean(context.Background()); err == nil {
+		t.Fatal("Clean() succeeded, want the cache cleaning error")
+	}
+	if calls := callArgs(fake); len(calls) != 1 {
+		t.Errorf("Clean() ran %q after the cache cleaning failed", calls)
+	}
+}
+
+func TestParsePacmanPrint(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		action ChangeAction
+		want   *ChangeSet
+	}{
+		{
+			name:   "nothing to do",
+			output: " there is nothing to do\n",
+			action: ActionUpgrade,
+			want:   &ChangeSet{},
+		},
+		{
+			name: "install",
+			output: `warning: vim-9.1.0785-1 is up to date -- reinstalling
+vim-runtime 9.1.0785-1 7896128
+vim 9.1.0785-1 1953456
+`,
+			action: ActionInstall,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionInstall, Name: "vim-runtime", Version: "9.1.0785-1", Size: 7_896_128},
+					{Action: ActionInstall, Name: "vim", Version: "9.1.0785-1", Size: 1_953_456},
+				},
+				DownloadSize: 9_849_584,
+			},
+		},
+		{
+			name:   "remove downloads nothing",
+			output: "vim 9.1.0785-1 4378624\n",
+			action: ActionRemove,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionRemove, Name: "vim", Version: "9.1.0785-1", Size: 4_378_624},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePacmanPrint(tt.output, tt.action); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePacmanPrint() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
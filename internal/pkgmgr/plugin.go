@@ -0,0 +1,393 @@
+package pkgmgr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// External backends are executables named lazylinux-backend-<name> on PATH.
+// lazylinux runs the executable once per operation, writes a single JSON
+// request to its stdin and reads a single JSON response from its stdout:
+//
+//	→ {"version": 1, "method": "search", "params": {"term": "numpy"}}
+//	← {"version": 1, "result": [{"name": "numpy", "version": "2.0.1", "source": "conda"}]}
+//
+// Methods and their params/results:
+//
+//	describe                          → {"name", "display_name", "capabilities": ["search", ...]}
+//	search   {"term"}                 → [Package]
+//	info     {"name"}                 → Package
+//	list                              → [Package]
+//	install  {"packages": [string]}   → null
+//	remove   {"packages": [string]}   → null
+//	update                            → null
+//	clean                             → null (optional)
+//
+// Failures are reported as {"version": 1, "error": {"code": "not_found",
+// "message": "..."}}. Codes are "not_found", "permission_denied", "locked",
+// "network", "conflict", "disk_full", "aborted", "unsupported_method" and
+// "failed". Anything written to stderr is shown to the user, so backends
+// should print progress there and keep stdout for the response.
+//
+// PluginProtocolVersion is the version of this protocol
+const PluginProtocolVersion = 1
+
+// pluginPrefix is the executable name prefix used to discover plugins
+const pluginPrefix = "lazylinux-backend-"
+
+// pluginDescribeTimeout bounds how long discovery waits for "describe"
+var pluginDescribeTimeout = 3 * time.Second
+
+type pluginRequest struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type pluginResponse struct {
+	Version int             `json:"version"`
+	Result  json.RawMessage `json:"result"`
+	Error   *PluginError    `json:"error"`
+}
+
+// PluginError is an error reported by an external backend
+type PluginError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *PluginError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// pluginErrorCodes maps protocol error codes to failure classes
+var pluginErrorCodes = map[string]error{
+	"not_found":         ErrPackageNotFound,
+	"permission_denied": ErrPermissionDenied,
+	"locked":            ErrDatabaseLocked,
+	"network":           ErrNetworkUnavailable,
+	"conflict":          ErrDependencyConflict,
+	"disk_full":         ErrDiskFull,
+	"aborted":           ErrAborted,
+}
+
+// Is matches the failure class for the error code
+func (e *PluginError) Is(target error) bool {
+	return pluginErrorCodes[e.Code] == target
+}
+
+type pluginDescription struct {
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"display_name"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// Plugin is an external backend driven over the stdio protocol
+type Plugin struct {
+	name string
+	path string
+
+	// lookups remembers info results, so checking whether a package is
+	// available and then installed runs the plugin once
+	mu      sync.Mutex
+	lookups map[string]pluginLookup
+}
+
+type pluginLookup struct {
+	pkg Package
+	err error
+}
+
+// NewPlugin creates a plugin backend for the executable at path
+func NewPlugin(name, path string) *Plugin {
+	return &Plugin{name: name, path: path}
+}
+
+// Name returns the backend name, the part after "lazylinux-backend-"
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// call sends one request to the plugin and decodes the result into result
+// (which may be nil)
+func (p *Plugin) call(ctx context.Context, method string, params any, result any) error {
+	request, err := json.Marshal(pluginRequest{
+		Version: PluginProtocolVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	var stdout bytes.Buffer
+	cmd := newCommand(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return fmt.Errorf("could not start %s: %v", p.path, runErr)
+	}
+
+	var response pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("%s %s failed: %v", p.name, method, runErr)
+		}
+		return fmt.Errorf("%s returned an invalid response: %v", p.name, err)
+	}
+
+	if response.Version != PluginProtocolVersion {
+		return fmt.Errorf("%s speaks protocol version %d, expected %d", p.name, response.Version, PluginProtocolVersion)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if runErr != nil {
+		return fmt.Errorf("%s %s failed: %v", p.name, method, runErr)
+	}
+
+	if result != nil && len(response.Result) > 0 {
+		if err := json.Unmarshal(response.Result, result); err != nil {
+			return fmt.Errorf("%s returned an invalid %s result: %v", p.name, method, err)
+		}
+	}
+
+	return nil
+}
+
+// Install installs packages through the plugin
+func (p *Plugin) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+	defer p.forget()
+	return p.call(ctx, "install", map[string]any{"packages": packages}, nil)
+}
+
+// Remove removes packages through the plugin
+func (p *Plugin) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+	defer p.forget()
+	return p.call(ctx, "remove", map[string]any{"packages": packages}, nil)
+}
+
+// Update updates everything the plugin manages
+func (p *Plugin) Update(ctx context.Context) error {
+	defer p.forget()
+	return p.call(ctx, "update", nil, nil)
+}
+
+// Clean asks the plugin to clean up; plugins may leave this out
+func (p *Plugin) Clean(ctx context.Context) error {
+	err := p.call(ctx, "clean", nil, nil)
+	if pluginErr, ok := err.(*PluginError); ok && pluginErr.Code == "unsupported_method" {
+		fmt.Println("✨ Nothing to clean")
+		return nil
+	}
+	return err
+}
+
+// List lists the packages installed through the plugin
+func (p *Plugin) List(ctx context.Context) ([]Package, error) {
+	var packages []Package
+	err := p.call(ctx, "list", nil, &packages)
+	return p.tag(packages), err
+}
+
+// Search searches the plugin's source for term
+func (p *Plugin) Search(ctx context.Context, term string) ([]Package, error) {
+	var packages []Package
+	err := p.call(ctx, "search", map[string]any{"term": term}, &packages)
+	return p.tag(packages), err
+}
+
+// Info returns details for a single package
+func (p *Plugin) Info(ctx context.Context, name string) (*Package, error) {
+	pkg, err := p.lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// IsAvailable checks for an exact package name through info
+func (p *Plugin) IsAvailable(ctx context.Context, name string) bool {
+	_, err := p.lookup(ctx, name)
+	return err == nil
+}
+
+// IsInstalled checks the installed flag reported by info
+func (p *Plugin) IsInstalled(ctx context.Context, name string) bool {
+	pkg, err := p.lookup(ctx, name)
+	return err == nil && pkg.Installed
+}
+
+// lookup runs info for name once. Found and not found answers are kept
+// until a transaction changes what is installed; other failures, like a
+// timeout, are retried on the next lookup.
+func (p *Plugin) lookup(ctx context.Context, name string) (Package, error) {
+	p.mu.Lock()
+	cached, ok := p.lookups[name]
+	p.mu.Unlock()
+	if ok {
+		return cached.pkg, cached.err
+	}
+
+	var pkg Package
+	err := p.call(ctx, "info", map[string]any{"name": name}, &pkg)
+	if err == nil && pkg.Name == "" {
+		err = fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+	if err != nil {
+		pkg = Package{}
+	}
+	pkg.Source = p.name
+
+	if err == nil || errors.Is(err, ErrPackageNotFound) {
+		p.mu.Lock()
+		if p.lookups == nil {
+			p.lookups = map[string]pluginLookup{}
+		}
+		p.lookups[name] = pluginLookup{pkg: pkg, err: err}
+		p.mu.Unlock()
+	}
+
+	return pkg, err
+}
+
+// forget drops remembered info results after a transaction
+func (p *Plugin) forget() {
+	p.mu.Lock()
+	p.lookups = nil
+	p.mu.Unlock()
+}
+
+// tag marks packages as coming from this plugin
+func (p *Plugin) tag(packages []Package) []Package {
+	for i := range packages {
+		packages[i].Source = p.name
+	}
+	return packages
+}
+
+// DiscoverPlugins registers every lazylinux-backend-<name> executable on
+// PATH as an add-on source. The first executable found for a name wins,
+// and plugins can't replace built-in backends. Plugins describe
+// themselves concurrently, so a slow one costs at most one timeout.
+func DiscoverPlugins() []error {
+	var errs []error
+	var names, paths []string
+	seen := map[string]bool{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, found := strings.CutPrefix(entry.Name(), pluginPrefix)
+			if !found || name == "" || seen[name] {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			seen[name] = true
+
+			if _, exists := LookupBackend(name); exists {
+				errs = append(errs, fmt.Errorf("plugin %s ignored: a backend named %s already exists", path, name))
+				continue
+			}
+			names, paths = append(names, name), append(paths, path)
+		}
+	}
+
+	failures := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := registerPlugin(names[i], paths[i]); err != nil {
+				failures[i] = fmt.Errorf("plugin %s ignored: %v", paths[i], err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range failures {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// registerPlugin asks the plugin to describe itself and registers it
+func registerPlugin(name, path string) error {
+	plugin := NewPlugin(name, path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginDescribeTimeout)
+	defer cancel()
+
+	var description pluginDescription
+	if err := plugin.call(ctx, "describe", nil, &description); err != nil {
+		return err
+	}
+
+	Register(Backend{
+		Name:         name,
+		DisplayName:  description.DisplayName,
+		Icon:         "🔌",
+		Priority:     100,
+		Capabilities: parseCapabilities(description.Capabilities),
+		Detect:       func() bool { return true },
+		Enabled: func(cfg *config.Config) bool {
+			return cfg == nil || !slices.Contains(cfg.DisabledPlugins, name)
+		},
+		New: func(cfg *config.Config) PackageManager { return NewPlugin(name, path) },
+	})
+
+	return nil
+}
+
+// parseCapabilities converts capability names back into flags,
+// ignoring names this version doesn't know
+func parseCapabilities(names []string) Capability {
+	var caps Capability
+	for _, name := range names {
+		for _, entry := range capabilityNames {
+			if entry.name == name {
+				caps |= entry.cap
+			}
+		}
+	}
+	return caps
+}
@@ -0,0 +1,299 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPluginPath = "/usr/bin/lazylinux-backend-conda"
+
+// blockingExecutor runs commands that never finish on their own
+type blockingExecutor struct{}
+
+func (blockingExecutor) Execute(ctx context.Context, cmd *Command) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (blockingExecutor) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+// pluginReplies makes the test plugin answer each call with the next
+// response, in order
+func pluginReplies(t *testing.T, responses ...Fixture) *FakeExecutor {
+	t.Helper()
+
+	fake := useFixtures(t)
+	for _, response := range responses {
+		response.Args = []string{testPluginPath}
+		fake.Add(response)
+	}
+	return fake
+}
+
+func TestPluginRequest(t *testing.T) {
+	fake := pluginReplies(t, Fixture{
+		Stdout: `{"version": 1, "result": [{"name": "numpy", "version": "2.0.1"}]}`,
+	})
+
+	packages, err := NewPlugin("conda", testPluginPath).Search(context.Background(), "numpy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{{Name: "numpy", Version: "2.0.1", Source: "conda"}}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("Search() = %+v, want %+v", packages, want)
+	}
+
+	request := `{"version":1,"method":"search","params":{"term":"numpy"}}`
+	if calls := fake.Calls(); len(calls) != 1 || calls[0].Stdin != request {
+		t.Errorf("Search() sent %+v, want one request %s", calls, request)
+	}
+}
+
+func TestPluginResponseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response Fixture
+		wantErr  string
+	}{
+		{
+			name:     "newer protocol",
+			response: Fixture{Stdout: `{"version": 2, "result": null}`},
+			wantErr:  "conda speaks protocol version 2, expected 1",
+		},
+		{
+			name:     "missing version",
+			response: Fixture{Stdout: `{"result": null}`},
+			wantErr:  "conda speaks protocol version 0, expected 1",
+		},
+		{
+			name:     "not json",
+			response: Fixture{Stdout: "Updating conda...\n"},
+			wantErr:  "conda returned an invalid response",
+		},
+		{
+			name:     "crash without a response",
+			response: Fixture{ExitCode: 1},
+			wantErr:  "conda update failed",
+		},
+		{
+			name:     "non-zero exit with a result",
+			response: Fixture{Stdout: `{"version": 1, "result": null}`, ExitCode: 2},
+			wantErr:  "conda update failed",
+		},
+		{
+			name:     "error without a code",
+			response: Fixture{Stdout: `{"version": 1, "error": {"message": "channel unreachable"}}`, ExitCode: 1},
+			wantErr:  "channel unreachable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pluginReplies(t, tt.response)
+
+			err := NewPlugin("conda", testPluginPath).Update(context.Background())
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Update() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPluginInvalidResult(t *testing.T) {
+	pluginReplies(t, Fixture{Stdout: `{"version": 1, "result": {"name": "numpy"}}`})
+
+	_, err := NewPlugin("conda", testPluginPath).List(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid list result") {
+		t.Errorf("List() = %v, want an invalid result error", err)
+	}
+}
+
+func TestPluginErrorCodes(t *testing.T) {
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"not_found", ErrPackageNotFound},
+		{"permission_denied", ErrPermissionDenied},
+		{"locked", ErrDatabaseLocked},
+		{"network", ErrNetworkUnavailable},
+		{"conflict", ErrDependencyConflict},
+		{"disk_full", ErrDiskFull},
+		{"aborted", ErrAborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			pluginReplies(t, Fixture{
+				Stdout:   `{"version": 1, "error": {"code": "` + tt.code + `", "message": "numpy"}}`,
+				ExitCode: 1,
+			})
+
+			err := NewPlugin("conda", testPluginPath).Install(context.Background(), "numpy")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Install() = %v, want %v", err, tt.want)
+			}
+			for _, other := range pluginErrorCodes {
+				if other != tt.want && errors.Is(err, other) {
+					t.Errorf("Install() = %v, also matches %v", err, other)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown code", func(t *testing.T) {
+		pluginReplies(t, Fixture{Stdout: `{"version": 1, "error": {"code": "failed", "message": "solver crashed"}}`, ExitCode: 1})
+
+		err := NewPlugin("conda", testPluginPath).Install(context.Background(), "numpy")
+		for _, class := range pluginErrorCodes {
+			if errors.Is(err, class) {
+				t.Errorf("Install() = %v, matches %v", err, class)
+			}
+		}
+		if err == nil || err.Error() != "failed: solver crashed" {
+			t.Errorf("Install() = %v, want the plugin's message", err)
+		}
+	})
+}
+
+func TestPluginCleanUnsupported(t *testing.T) {
+	pluginReplies(t, Fixture{Stdout: `{"version": 1, "error": {"code": "unsupported_method", "message": "clean"}}`, ExitCode: 1})
+
+	if err := NewPlugin("conda", testPluginPath).Clean(context.Background()); err != nil {
+		t.Errorf("Clean() = %v, want nil for a plugin without clean", err)
+	}
+}
+
+func TestPluginLookups(t *testing.T) {
+	fake := pluginReplies(t,
+		Fixture{Stdout: `{"version": 1, "result": {"name": "numpy", "version": "2.0.1"}}`},
+		Fixture{Stdout: `{"version": 1, "result": null}`},
+		Fixture{Stdout: `{"version": 1, "result": {"name": "numpy", "version": "2.0.1", "installed": true}}`},
+	)
+
+	ctx := context.Background()
+	plugin := NewPlugin("conda", testPluginPath)
+
+	if !plugin.IsAvailable(ctx, "numpy") || plugin.IsInstalled(ctx, "numpy") {
+		t.Error("numpy should be available and not installed")
+	}
+	if pkg, err := plugin.Info(ctx, "numpy"); err != nil || pkg.Version != "2.0.1" || pkg.Source != "conda" {
+		t.Errorf("Info() = %+v, %v, want numpy 2.0.1 from conda", pkg, err)
+	}
+	if calls := len(fake.Calls()); calls != 1 {
+		t.Errorf("checking one package ran the plugin %d times, want once", calls)
+	}
+
+	if err := plugin.Install(ctx, "numpy"); err != nil {
+		t.Fatal(err)
+	}
+	if !plugin.IsInstalled(ctx, "numpy") {
+		t.Error("IsInstalled() answered from before the install")
+	}
+}
+
+func TestPluginLookupNotFound(t *testing.T) {
+	fake := pluginReplies(t, Fixture{Stdout: `{"version": 1, "result": {}}`})
+
+	ctx := context.Background()
+	plugin := NewPlugin("conda", testPluginPath)
+
+	if _, err := plugin.Info(ctx, "nupmy"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("Info() = %v, want %v", err, ErrPackageNotFound)
+	}
+	if plugin.IsAvailable(ctx, "nupmy") {
+		t.Error("IsAvailable() = true for a package the plugin doesn't know")
+	}
+	if calls := len(fake.Calls()); calls != 1 {
+		t.Errorf("checking one package ran the plugin %d times, want once", calls)
+	}
+}
+
+func TestPluginLookupRetriesFailures(t *testing.T) {
+	fake := pluginReplies(t,
+		Fixture{ExitCode: 1},
+		Fixture{Stdout: `{"version": 1, "result": {"name": "numpy"}}`},
+	)
+
+	ctx := context.Background()
+	plugin := NewPlugin("conda", testPluginPath)
+
+	if plugin.IsAvailable(ctx, "numpy") {
+		t.Error("IsAvailable() = true after the plugin crashed")
+	}
+	if !plugin.IsAvailable(ctx, "numpy") {
+		t.Error("IsAvailable() kept the failure instead of asking again")
+	}
+	if calls := len(fake.Calls()); calls != 2 {
+		t.Errorf("ran the plugin %d times, want 2", calls)
+	}
+}
+
+func TestRegisterPluginTimeout(t *testing.T) {
+	previousExecutor := SetExecutor(blockingExecutor{})
+	previousTimeout := pluginDescribeTimeout
+	pluginDescribeTimeout = 10 * time.Millisecond
+	t.Cleanup(func() {
+		SetExecutor(previousExecutor)
+		pluginDescribeTimeout = previousTimeout
+	})
+
+	err := registerPlugin("conda", testPluginPath)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("registerPlugin() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, ok := LookupBackend("conda"); ok {
+		t.Error("registerPlugin() registered a plugin that never described itself")
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"lazylinux-backend-conda", "lazylinux-backend-dnf", "lazylinux-backend-notes"} {
+		mode := os.FileMode(0o755)
+		if name == "lazylinux-backend-notes" {
+			mode = 0o644
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	fake := useFixtures(t)
+	fake.Add(Fixture{
+		Args:   []string{filepath.Join(dir, "lazylinux-backend-conda")},
+		Stdout: `{"version": 1, "result": {"name": "conda", "display_name": "Conda", "capabilities": ["search", "teleport"]}}`,
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "conda")
+		registryMu.Unlock()
+	})
+
+	errs := DiscoverPlugins()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "a backend named dnf already exists") {
+		t.Errorf("DiscoverPlugins() = %v, want only the dnf conflict", errs)
+	}
+
+	backend, ok := LookupBackend("conda")
+	if !ok {
+		t.Fatal("DiscoverPlugins() didn't register conda")
+	}
+	if backend.DisplayName != "Conda" || backend.Capabilities != CapSearch || backend.Native {
+		t.Errorf("registered %+v, want the described Conda source", backend)
+	}
+	if _, ok := LookupBackend("notes"); ok {
+		t.Error("DiscoverPlugins() registered a file that isn't executable")
+	}
+}
@@ -0,0 +1,82 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// EscalationTools are the supported ways to run commands as root, in the
+// order they are tried when none is configured
+var EscalationTools = []string{"sudo", "doas", "run0", "pkexec"}
+
+var (
+	escalationMu   sync.RWMutex
+	escalationTool string // Configured tool, empty to detect
+
+	// isRoot reports whether lazylinux already runs as root
+	isRoot = func() bool { return os.Geteuid() == 0 }
+)
+
+// SetEscalationTool picks the tool used to run commands as root. An
+// empty name means detect it from EscalationTools.
+func SetEscalationTool(tool string) error {
+	if tool != "" && !slices.Contains(EscalationTools, tool) {
+		return fmt.Errorf("unknown escalation tool %q (supported: %s)", tool, strings.Join(EscalationTools, ", "))
+	}
+
+	escalationMu.Lock()
+	defer escalationMu.Unlock()
+	escalationTool = tool
+	return nil
+}
+
+// EscalationTool returns the tool privileged commands go through, or ""
+// when lazylinux already runs as root
+func EscalationTool() string {
+	if isRoot() {
+		return ""
+	}
+
+	escalationMu.RLock()
+	tool := escalationTool
+	escalationMu.RUnlock()
+	if tool != "" {
+		return tool
+	}
+
+	for _, candidate := range EscalationTools {
+		if commandExists(candidate) {
+			return candidate
+		}
+	}
+
+	// Nothing found: keep sudo so the error names what's missing
+	return "sudo"
+}
+
+// privilegedCommand prepares a command that needs root, going through the
+// escalation tool unless we are root already. Running it first waits for
+// the package manager's locks.
+func privilegedCommand(ctx context.Context, name string, args ...string) *Command {
+	var cmd *Command
+	if tool := EscalationTool(); tool == "" {
+		cmd = newCommand(ctx, name, args...)
+	} else {
+		cmd = newCommand(ctx, tool, append([]string{name}, args...)...)
+	}
+	cmd.lockProgram = name
+	return cmd
+}
+
+// privilegedDryRun prepares a dry run for a package manager that only
+// resolves transactions as root. A dry run changes nothing, so unlike a
+// transaction it doesn't wait for the package manager's locks.
+func privilegedDryRun(ctx context.Context, name string, args ...string) *Command {
+	cmd := privilegedCommand(ctx, name, args...)
+	cmd.lockProgram = ""
+	return cmd
+}
@@ -0,0 +1,87 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSetEscalationTool(t *testing.T) {
+	useFixtures(t)
+
+	for _, tool := range append([]string{""}, EscalationTools...) {
+		if err := SetEscalationTool(tool); err != nil {
+			t.Errorf("SetEscalationTool(%q) = %v", tool, err)
+		}
+	}
+
+	if err := SetEscalationTool("su"); err == nil {
+		t.Error("SetEscalationTool(\"su\") succeeded for an unsupported tool")
+	}
+	if got := EscalationTool(); got != "pkexec" {
+		t.Errorf("EscalationTool() = %q after a rejected tool, want the previous %q", got, "pkexec")
+	}
+}
+
+func TestEscalationTool(t *testing.T) {
+	tests := []struct {
+		name       string
+		root       bool
+		configured string
+		installed  []string
+		want       string
+	}{
+		{name: "root needs nothing", root: true, configured: "doas", installed: []string{"sudo"}, want: ""},
+		{name: "configured", configured: "run0", installed: []string{"sudo"}, want: "run0"},
+		{name: "sudo first", installed: []string{"pkexec", "doas", "sudo"}, want: "sudo"},
+		{name: "doas", installed: []string{"pkexec", "doas"}, want: "doas"},
+		{name: "pkexec", installed: []string{"pkexec"}, want: "pkexec"},
+		{name: "none installed", installed: nil, want: "sudo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFixtures(t)
+			fake.AddPath(tt.installed...)
+			isRoot = func() bool { return tt.root }
+			if err := SetEscalationTool(tt.configured); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := EscalationTool(); got != tt.want {
+				t.Errorf("EscalationTool() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivilegedCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		root bool
+		tool string
+		want []string
+	}{
+		{name: "sudo", tool: "sudo", want: []string{"sudo", "dnf", "install", "-y", "vim"}},
+		{name: "doas", tool: "doas", want: []string{"doas", "dnf", "install", "-y", "vim"}},
+		{name: "root", root: true, tool: "sudo", want: []string{"dnf", "install", "-y", "vim"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFixtures(t)
+			isRoot = func() bool { return tt.root }
+			if err := SetEscalationTool(tt.tool); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := privilegedCommand(context.Background(), "dnf", "install", "-y", "vim")
+			if got := append([]string{cmd.Name}, cmd.Args...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("privilegedCommand() = %q, want %q", got, tt.want)
+			}
+			if cmd.lockProgram != "dnf" {
+				t.Errorf("privilegedCommand() waits for %q's locks, want dnf's", cmd.lockProgram)
+			}
+		})
+	}
+}
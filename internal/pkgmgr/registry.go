@@ -0,0 +1,183 @@
+package pkgmgr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// Capability describes optional features a backend supports
+type Capability uint
+
+const (
+	// CapSearch means Search is fast enough to run while resolving a package
+	CapSearch Capability = 1 << iota
+	// CapUserScope means packages are installed per user, without root
+	CapUserScope
+	// CapChannels means the backend can track release channels
+	CapChannels
+	// CapOrphans means Clean also removes packages nothing depends on
+	CapOrphans
+	// CapInstallReason means List tells explicit installs from dependencies
+	CapInstallReason
+)
+
+var capabilityNames = []struct {
+	cap  Capability
+	name string
+}{
+	{CapSearch, "search"},
+	{CapUserScope, "user-scope"},
+	{CapChannels, "channels"},
+	{CapOrphans, "orphans"},
+	{CapInstallReason, "install-reason"},
+}
+
+// Has reports whether all capabilities in other are set
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+// String lists the capability names, e.g. "search, orphans"
+func (c Capability) String() string {
+	names := []string{}
+	for _, entry := range capabilityNames {
+		if c.Has(entry.cap) {
+			names = append(names, entry.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Backend describes a package manager lazylinux can drive
+type Backend struct {
+	Name         string // Config and source name, e.g. "dnf"
+	DisplayName  string // Shown to users, e.g. "DNF"
+	Icon         string // Used in section headers
+	Native       bool   // System package manager rather than an add-on source
+	Priority     int    // Detection order for native backends, display order for sources
+	Capabilities Capability
+
+	// Detect reports whether the backend is usable on this machine
+	Detect func() bool
+	// Enabled reports whether an add-on source is turned on in the config
+	Enabled func(cfg *config.Config) bool
+	// New creates the backend; cfg may be nil
+	New func(cfg *config.Config) PackageManager
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Backend{}
+)
+
+// Register adds a backend to the registry. Backends register themselves
+// from init functions; registering the same name twice panics.
+func Register(backend Backend) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if backend.Name == "" || backend.New == nil || backend.Detect == nil {
+		panic("pkgmgr: Register called with an incomplete backend")
+	}
+	if _, exists := registry[backend.Name]; exists {
+		panic("pkgmgr: Register called twice for backend " + backend.Name)
+	}
+	if backend.DisplayName == "" {
+		backend.DisplayName = backend.Name
+	}
+	if backend.Icon == "" {
+		backend.Icon = "📦"
+	}
+
+	registry[backend.Name] = backend
+}
+
+// Backends returns all registered backends, native ones first in
+// detection order, then add-on sources
+func Backends() []Backend {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	backends := make([]Backend, 0, len(registry))
+	for _, backend := range registry {
+		backends = append(backends, backend)
+	}
+
+	sort.Slice(backends, func(i, j int) bool {
+		a, b := backends[i], backends[j]
+		if a.Native != b.Native {
+			return a.Native
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.Name < b.Name
+	})
+
+	return backends
+}
+
+// LookupBackend finds a registered backend by name
+func LookupBackend(name string) (Backend, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	backend, ok := registry[name]
+	return backend, ok
+}
+
+// NewBackend creates the named backend
+func NewBackend(name string, cfg *config.Config) (PackageManager, error) {
+	backend, ok := LookupBackend(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown package manager: %s", name)
+	}
+	return backend.New(cfg), nil
+}
+
+// NativeBackendNames lists the native backends in detection order
+func NativeBackendNames() []string {
+	names := []string{}
+	for _, backend := range Backends() {
+		if backend.Native {
+			names = append(names, backend.Name)
+		}
+	}
+	return names
+}
+
+// EnabledSources creates every add-on source turned on in the config
+func EnabledSources(cfg *config.Config) []PackageManager {
+	sources := []PackageManager{}
+	for _, backend := range Backends() {
+		if backend.Native || backend.Enabled == nil || !backend.Enabled(cfg) {
+			continue
+		}
+		sources = append(sources, backend.New(cfg))
+	}
+	return sources
+}
+
+// DisplayName returns the user facing name of a package manager
+func DisplayName(pm PackageManager) string {
+	return SourceDisplayName(pm.Name())
+}
+
+// SourceDisplayName returns the user facing name for a backend name
+func SourceDisplayName(name string) string {
+	if backend, ok := LookupBackend(name); ok {
+		return backend.DisplayName
+	}
+	return name
+}
+
+// isNativeSource reports whether a source is the system package manager
+// rather than an add-on source such as Flatpak
+func isNativeSource(name string) bool {
+	backend, ok := LookupBackend(name)
+	return !ok || backend.Native
+}
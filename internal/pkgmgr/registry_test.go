@@ -0,0 +1,193 @@
+package pkgmgr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// registerBackend registers backend for the rest of the test
+func registerBackend(t *testing.T, backend Backend) {
+	t.Helper()
+
+	Register(backend)
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, backend.Name)
+		registryMu.Unlock()
+	})
+}
+
+func TestCapabilityString(t *testing.T) {
+	tests := []struct {
+		cap  Capability
+		want string
+	}{
+		{0, ""},
+		{CapSearch, "search"},
+		{CapSearch | CapOrphans, "search, orphans"},
+		{CapInstallReason | CapUserScope | CapChannels, "user-scope, channels, install-reason"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cap.String(); got != tt.want {
+			t.Errorf("Capability(%d).String() = %q, want %q", tt.cap, got, tt.want)
+		}
+	}
+}
+
+func TestCapabilityHas(t *testing.T) {
+	caps := CapSearch | CapOrphans
+
+	tests := []struct {
+		other Capability
+		want  bool
+	}{
+		{CapSearch, true},
+		{CapSearch | CapOrphans, true},
+		{CapChannels, false},
+		{CapSearch | CapChannels, false},
+		{0, true},
+	}
+
+	for _, tt := range tests {
+		if got := caps.Has(tt.other); got != tt.want {
+			t.Errorf("Has(%q) = %v, want %v", tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterDefaults(t *testing.T) {
+	registerBackend(t, Backend{
+		Name:   "test-defaults",
+		Detect: func() bool { return true },
+		New:    func(cfg *config.Config) PackageManager { return nil },
+	})
+
+	backend, ok := LookupBackend("test-defaults")
+	if !ok {
+		t.Fatal("LookupBackend() didn't find the registered backend")
+	}
+	if backend.DisplayName != "test-defaults" || backend.Icon != "📦" {
+		t.Errorf("Register() set DisplayName %q and Icon %q, want the name and the default icon", backend.DisplayName, backend.Icon)
+	}
+	if got := SourceDisplayName("test-defaults"); got != "test-defaults" {
+		t.Errorf("SourceDisplayName() = %q, want %q", got, "test-defaults")
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	detect := func() bool { return true }
+	create := func(cfg *config.Config) PackageManager { return nil }
+
+	tests := []struct {
+		name    string
+		backend Backend
+	}{
+		{name: "no name", backend: Backend{Detect: detect, New: create}},
+		{name: "no constructor", backend: Backend{Name: "test-incomplete", Detect: detect}},
+		{name: "no detection", backend: Backend{Name: "test-incomplete", New: create}},
+		{name: "duplicate", backend: Backend{Name: "dnf", Detect: detect, New: create}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register() didn't panic")
+				}
+			}()
+			Register(tt.backend)
+		})
+	}
+}
+
+func TestBackendsOrder(t *testing.T) {
+	var names []string
+	for _, backend := range Backends() {
+		names = append(names, backend.Name)
+	}
+
+	want := []string{"dnf", "apt", "pacman", "zypper", "apk", "xbps", "flatpak", "snap", "aur", "nix"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Backends() = %v, want %v", names, want)
+	}
+
+	if got, want := NativeBackendNames(), want[:6]; !reflect.DeepEqual(got, want) {
+		t.Errorf("NativeBackendNames() = %v, want %v", got, want)
+	}
+}
+
+func TestEnabledSources(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want []string
+	}{
+		{
+			name: "none",
+			cfg:  config.Config{PackageManager: "dnf"},
+			want: []string{},
+		},
+		{
+			name: "flatpak and nix",
+			cfg:  config.Config{PackageManager: "dnf", FlatpakEnabled: true, NixEnabled: true},
+			want: []string{"flatpak", "nix"},
+		},
+		{
+			name: "aur without pacman",
+			cfg:  config.Config{PackageManager: "dnf", AUREnabled: true},
+			want: []string{},
+		},
+		{
+			name: "aur with pacman",
+			cfg:  config.Config{PackageManager: "pacman", AUREnabled: true, SnapEnabled: true},
+			want: []string{"snap", "aur"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			for _, pm := range EnabledSources(&tt.cfg) {
+				names = append(names, pm.Name())
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("EnabledSources() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	pm, err := NewBackend("zypper", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Name() != "zypper" || DisplayName(pm) != "Zypper" {
+		t.Errorf("NewBackend() = %s (%s), want zypper (Zypper)", pm.Name(), DisplayName(pm))
+	}
+
+	if _, err := NewBackend("emerge", nil); err == nil {
+		t.Error("NewBackend() succeeded for an unknown backend")
+	}
+}
+
+func TestIsNativeSource(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"apt", true},
+		{"flatpak", false},
+		{"aur", false},
+		{"unknown", true},
+	}
+
+	for _, tt := range tests {
+		if got := isNativeSource(tt.name); got != tt.want {
+			t.Errorf("isNativeSource(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
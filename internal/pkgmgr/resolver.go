@@ -2,9 +2,10 @@ package pkgmgr
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
@@ -12,291 +13,220 @@ import (
 
 // PackageSource represents where a package was found
 type PackageSource struct {
-	Manager     string // "dnf", "apt", "pacman", "flatpak"
+	Manager     string // Backend name: "dnf", "apt", "flatpak", ...
 	PackageName string // The actual package name (might be different for Flatpak)
 	Available   bool   // Whether it's available in this source
 	Confidence  int    // Match confidence (0-100) - higher = better match
 }
 
+// maxConcurrentResolves limits how many packages ResolvePackages looks
+// up at once; each lookup already runs one query per source
+const maxConcurrentResolves = 4
+
 // ResolvePackage finds which package manager(s) have the package
-func ResolvePackage(packageName string, nativePM PackageManager, hasFlatpak bool) []PackageSource {
-	sources := []PackageSource{}
+func ResolvePackage(ctx context.Context, packageName string, nativePM PackageManager, extraSources []PackageManager) []PackageSource {
+	for _, source := range append([]PackageManager{nativePM}, extraSources...) {
+		fmt.Printf("  🔍 Searching in %s...\n", DisplayName(source))
+	}
+
+	sources := resolvePackage(ctx, packageName, nativePM, extraSources)
+	reportTranslation(packageName, sources)
+	return sources
+}
 
+// ResolvePackages resolves several packages concurrently. The result
+// holds the sources for each name, in the same order as names.
+func ResolvePackages(ctx context.Context, names []string, nativePM PackageManager, extraSources []PackageManager) [][]PackageSource {
+	fmt.Printf("  🔍 Searching in %s...\n", strings.Join(sourceNames(nativePM, extraSources), ", "))
+
+	results := make([][]PackageSource, len(names))
+	slots := make(chan struct{}, maxConcurrentResolves)
 	var wg sync.WaitGroup
 
-	nativeChan := make(chan PackageSource, 1)
-	flatpakChan := make(chan []PackageSource, 1)
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
 
-	wg.Add(2)
+			results[i] = resolvePackage(ctx, name, nativePM, extraSources)
+		}()
+	}
+
+	wg.Wait()
+	for i, name := range names {
+		reportTranslation(name, results[i])
+	}
+	return results
+}
+
+// sourceNames returns the display names of the native manager and sources
+func sourceNames(nativePM PackageManager, extraSources []PackageManager) []string {
+	names := []string{DisplayName(nativePM)}
+	for _, source := range extraSources {
+		names = append(names, DisplayName(source))
+	}
+	return names
+}
+
+// resolvePackage queries every source for a package at the same time
+func resolvePackage(ctx context.Context, packageName string, nativePM PackageManager, extraSources []PackageManager) []PackageSource {
+	var wg sync.WaitGroup
+
+	// One slot per source so results keep a stable order
+	results := make([][]PackageSource, len(extraSources)+1)
+
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		fmt.Printf("  🔍 Searching in %s...\n", getPackageManagerName(nativePM))
-		available := checkNativePackage(packageName, nativePM)
-		nativeChan <- PackageSource{
-			Manager:     getPackageManagerName(nativePM),
-			PackageName: packageName,
-			Available:   available,
+		available := func(name string) bool { return isAvailable(ctx, nativePM, name) }
+		name := nativeCandidate(packageName, nativePM.Name(), available)
+		results[0] = []PackageSource{{
+			Manager:     nativePM.Name(),
+			PackageName: name,
+			Available:   available(name),
 			Confidence:  100, // Exact match in native
-		}
+		}}
 	}()
 
-	go func() {
-		defer wg.Done()
-		if !hasFlatpak {
-			flatpakChan <- []PackageSource{} // Send empty result
-			return
-		}
-		fmt.Println("  🔍 Searching in Flatpak...")
-		flatpakMatches := searchFlatpakPackages(packageName)
-		flatpakChan <- flatpakMatches
-	}()
+	for i, source := range extraSources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i+1] = searchSource(ctx, packageName, source)
+		}()
+	}
 
 	wg.Wait()
 
-	sources = append(sources, <-nativeChan)
-	sources = append(sources, <-flatpakChan...)
-
-	close(nativeChan)
-	close(flatpakChan)
+	sources := []PackageSource{}
+	for _, result := range results {
+		sources = append(sources, result...)
+	}
 
 	return sources
 }
 
-// checkNativePackage checks if package exists in native package manager
-func checkNativePackage(packageName string, pm PackageManager) bool {
-	var cmd *exec.Cmd
-
-	switch pm.(type) {
-	case *DNF:
-		// dnf repoquery <package> (quiet check)
-		cmd = exec.Command("dnf", "repoquery", packageName)
-		output, err := cmd.Output()
-		if err != nil || len(output) == 0 {
-			return false
-		}
-		return true
-	case *APT:
-		// apt-cache search <package>
-		cmd = exec.Command("apt-cache", "search", "--names-only", "^"+packageName+"$")
-		output, err := cmd.Output()
-		if err != nil || len(output) == 0 {
-			return false
-		}
-		return true
-	case *Pacman:
-		// pacman -Ss <package>
-		cmd = exec.Command("pacman", "-Ss", "^"+packageName+"$")
-		output, err := cmd.Output()
-		if err != nil || len(output) == 0 {
-			return false
-		}
-		return true
-	default:
-		return false
-	}
-}
-
 // ResolvePackageForRemove finds INSTALLED packages to remove
-func ResolvePackageForRemove(packageName string, nativePM PackageManager, hasFlatpak bool) []PackageSource {
-	sources := []PackageSource{}
-
+func ResolvePackageForRemove(ctx context.Context, packageName string, nativePM PackageManager, extraSources []PackageManager) []PackageSource {
 	var wg sync.WaitGroup
 
-	nativeChan := make(chan PackageSource, 1)
-	flatpakChan := make(chan []PackageSource, 1)
-
-	wg.Add(2)
+	results := make([][]PackageSource, len(extraSources)+1)
 
 	// Check native - INSTALLED packages only
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		fmt.Printf("  🔍 Searching in %s...\n", getPackageManagerName(nativePM))
-		nativeInstalled := checkInstalledPackages(packageName, nativePM)
-		nativeChan <- PackageSource{
-			Manager:     getPackageManagerName(nativePM),
-			PackageName: packageName,
-			Available:   nativeInstalled,
+		fmt.Printf("  🔍 Searching in %s...\n", DisplayName(nativePM))
+		name := removeCandidate(packageName, nativePM.Name())
+		results[0] = []PackageSource{{
+			Manager:     nativePM.Name(),
+			PackageName: name,
+			Available:   nativePM.IsInstalled(ctx, name),
 			Confidence:  100,
-		}
+		}}
 	}()
 
-	go func() {
-		defer wg.Done()
-		if !hasFlatpak {
-			flatpakChan <- []PackageSource{} // Send empty result
-			return
-		}
-		fmt.Println("  🔍 Searching in Flatpak...")
-		flatpakMatches := searchFlatpakInstalledPackages(packageName)
-		flatpakChan <- flatpakMatches
-	}()
+	for i, source := range extraSources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Printf("  🔍 Searching in %s...\n", DisplayName(source))
+			results[i+1] = searchInstalledSource(ctx, packageName, source)
+		}()
+	}
 
 	wg.Wait()
 
-	sources = append(sources, <-nativeChan)
-	sources = append(sources, <-flatpakChan...)
-
-	close(nativeChan)
-	close(flatpakChan)
+	sources := []PackageSource{}
+	for _, result := range results {
+		sources = append(sources, result...)
+	}
 
+	reportTranslation(packageName, sources)
 	return sources
 }
 
-// searchFlatpakInstalledPackages searches only INSTALLED flatpak apps
-func searchFlatpakInstalledPackages(packageName string) []PackageSource {
-	matches := []PackageSource{}
-
-	// List only installed apps
-	cmd := exec.Command("flatpak", "list", "--app", "--columns=application,name")
-	output, err := cmd.Output()
-
-	if err != nil || len(output) == 0 {
-		return matches
-	}
-
-	// Parse output
-	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
-
-	for line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		parts := strings.Split(line, "\t")
-		if len(parts) < 2 {
-			continue
+// searchSource searches an add-on source and returns all matching packages
+// with confidence scores
+func searchSource(ctx context.Context, packageName string, source PackageManager) []PackageSource {
+	backend, _ := LookupBackend(source.Name())
+
+	// Sources without a fast search (like Nix, which evaluates all of
+	// nixpkgs) only offer an exact name
+	if !backend.Capabilities.Has(CapSearch) {
+		if !isAvailable(ctx, source, packageName) {
+			reportSearchTimeout(ctx, source)
+			return []PackageSource{}
 		}
 
-		appID := strings.TrimSpace(parts[0])
-		appName := strings.TrimSpace(parts[1])
-
-		if appID == "" {
-			continue
-		}
-
-		// Calculate match confidence
-		confidence := calculateMatchConfidence(packageName, appName, appID)
-
-		if confidence < 75 {
-			continue
-		}
-
-		matches = append(matches, PackageSource{
-			Manager:     "flatpak",
-			PackageName: appID,
+		return []PackageSource{{
+			Manager:     source.Name(),
+			PackageName: packageName,
 			Available:   true,
-			Confidence:  confidence,
-		})
+			Confidence:  90, // Exact name, but outside the system package manager
+		}}
 	}
 
-	// Sort by confidence
-	for i := 0; i < len(matches); i++ {
-		for j := i + 1; j < len(matches); j++ {
-			if matches[j].Confidence > matches[i].Confidence {
-				matches[i], matches[j] = matches[j], matches[i]
-			}
-		}
+	packages, err := searchPackages(ctx, source, packageName)
+	if err != nil {
+		reportSearchTimeout(ctx, source)
+		return []PackageSource{}
 	}
+	return matchPackages(packageName, source.Name(), packages)
+}
 
-	if len(matches) > 5 {
-		return matches[:5]
+// searchInstalledSource searches only INSTALLED packages of an add-on source
+func searchInstalledSource(ctx context.Context, packageName string, source PackageManager) []PackageSource {
+	packages, err := source.List(ctx)
+	if err != nil {
+		reportSearchTimeout(ctx, source)
+		return []PackageSource{}
 	}
-
-	return matches
+	return matchPackages(packageName, source.Name(), packages)
 }
 
-func checkInstalledPackages(packageName string, pm PackageManager) bool {
-	var cmd *exec.Cmd
-	var output []byte
-	var err error
-
-	switch pm.(type) {
-	case *DNF:
-		cmd = exec.Command("rpm", "-q", packageName)
-		output, err = cmd.Output()
-		if err != nil || len(output) == 0 {
-			return false
-		}
-		return true
-
-	case *APT:
-		// dpkg-query -W -f='${Status}' <package> (check installed)
-		cmd = exec.Command("dpkg-query", "-W", "-f=${Status}", packageName)
-		output, err = cmd.Output()
-		if err != nil || len(output) == 0 {
-			return false
-		}
-		// Check if status contains "installed"
-		return strings.Contains(string(output), "installed")
-
-	case *Pacman:
-		// pacman -Q <package> (check installed)
-		cmd = exec.Command("pacman", "-Q", packageName)
-		output, err = cmd.Output()
-		if err != nil || len(output) == 0 {
-			return false
-		}
-		return true
-
-	default:
-		return false
-
+// reportSearchTimeout tells the user a source was skipped because the
+// search ran out of time, rather than silently finding nothing
+func reportSearchTimeout(ctx context.Context, source PackageManager) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Printf("  ⏱️  %s timed out, skipping it\n", DisplayName(source))
 	}
 }
 
-// searchFlatpakPackages searches Flatpak and returns all matching packages with confidence scores
-func searchFlatpakPackages(packageName string) []PackageSource {
+// matchPackages scores packages against the search term and returns the
+// best matches as sources for the given manager
+func matchPackages(packageName, manager string, packages []Package) []PackageSource {
 	matches := []PackageSource{}
 
-	// Search Flatpak with proper column format
-	// Format: --columns=application,name to get "app.id" and "App Name"
-	cmd := exec.Command("flatpak", "search", "--columns=application,name", packageName)
-	output, err := cmd.Output()
-
-	if err != nil || len(output) == 0 {
-		return matches
-	}
-
-	// Parse output line by line
-	// Format: org.zen_browser.zen	Zen Browser
-	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
-
-	for line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		// Split by tab
-		parts := strings.Split(line, "\t")
-		if len(parts) < 2 {
-			continue
-		}
-
-		appID := strings.TrimSpace(parts[0])
-		appName := strings.TrimSpace(parts[1])
-
-		// Skip if appID looks invalid
-		if appID == "" || !strings.Contains(appID, ".") {
-			continue
+	for _, pkg := range packages {
+		displayName := pkg.DisplayName
+		if displayName == "" {
+			displayName = pkg.Name
 		}
 
 		// Calculate match confidence
-		confidence := calculateMatchConfidence(packageName, appName, appID)
+		confidence := calculateMatchConfidence(packageName, displayName, pkg.Name)
 
 		if confidence < 75 {
 			continue
 		}
 
 		matches = append(matches, PackageSource{
-			Manager:     "flatpak",
-			PackageName: appID,
+			Manager:     manager,
+			PackageName: pkg.Name,
 			Available:   true,
 			Confidence:  confidence,
 		})
 	}
 
-	// Sort by confidence (best matches first)
+	return topMatches(matches)
+}
+
+// topMatches sorts matches by confidence and keeps the 5 best
+func topMatches(matches []PackageSource) []PackageSource {
 	for i := 0; i < len(matches); i++ {
 		for j := i + 1; j < len(matches); j++ {
 			if matches[j].Confidence > matches[i].Confidence {
@@ -305,7 +235,6 @@ func searchFlatpakPackages(packageName string) []PackageSource {
 		}
 	}
 
-	// Return only top 5 best matches (not all 25)
 	if len(matches) > 5 {
 		return matches[:5]
 	}
@@ -355,9 +284,7 @@ func calculateMatchConfidence(searchTerm, appName, appID string) int {
 func fuzzyMatchAppID(searchTerm, appID string) int {
 	// Split app ID by dots and underscores
 	// org.zen_browser.zen → ["org", "zen", "browser", "zen"]
-	parts := strings.FieldsFunc(appID, func(r rune) bool {
-		return r == '.' || r == '_' || r == '-'
-	})
+	parts := strings.FieldsFunc(appID, isNameSeparator)
 
 	for searchPart := range strings.SplitSeq(searchTerm, " ") {
 		for _, part := range parts {
@@ -378,15 +305,26 @@ func fuzzyMatchAppID(searchTerm, appID string) int {
 	return 0
 }
 
-// PromptUserChoice asks user to choose between multiple sources
-func PromptUserChoice(sources []PackageSource, packageName string) *PackageSource {
-	// Filter only available sources
+// isNameSeparator splits package names and app IDs into words
+func isNameSeparator(r rune) bool {
+	return r == '.' || r == '_' || r == '-'
+}
+
+// AvailableSources keeps the sources that have the package
+func AvailableSources(sources []PackageSource) []PackageSource {
 	available := []PackageSource{}
 	for _, src := range sources {
 		if src.Available {
 			available = append(available, src)
 		}
 	}
+	return available
+}
+
+// PromptUserChoice asks user to choose between multiple sources. It
+// returns nil if ctx is cancelled while waiting for an answer.
+func PromptUserChoice(ctx context.Context, sources []PackageSource, packageName string) *PackageSource {
+	available := AvailableSources(sources)
 
 	// No sources available
 	if len(available) == 0 {
@@ -396,10 +334,10 @@ func PromptUserChoice(sources []PackageSource, packageName string) *PackageSourc
 	// Only one source - use it without asking
 	if len(available) == 1 {
 		src := available[0]
-		if src.Manager == "flatpak" {
-			fmt.Printf("✅ Found in Flatpak: %s\n", src.PackageName)
+		if !isNativeSource(src.Manager) {
+			fmt.Printf("✅ Found in %s: %s\n", SourceDisplayName(src.Manager), src.PackageName)
 		} else {
-			fmt.Printf("✅ Found in %s\n", src.Manager)
+			fmt.Printf("✅ Found in %s\n", SourceDisplayName(src.Manager))
 		}
 		return &src
 	}
@@ -410,7 +348,7 @@ func PromptUserChoice(sources []PackageSource, packageName string) *PackageSourc
 	for i, src := range available {
 
 		displayName := src.PackageName
-		if src.Manager == "flatpak" {
+		if !isNativeSource(src.Manager) {
 			// Show both app name and ID for Flatpak
 			displayName = fmt.Sprintf("%s [%s]", src.PackageName, getConfidenceLabel(src.Confidence))
 		}
@@ -420,8 +358,11 @@ func PromptUserChoice(sources []PackageSource, packageName string) *PackageSourc
 
 	// Get user input
 	fmt.Print("\nChoose source only one: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
+	input, err := readLine(ctx)
+	if err != nil {
+		fmt.Println()
+		return nil
+	}
 	input = strings.TrimSpace(input)
 
 	// Default to 1 (first/best match)
@@ -439,6 +380,43 @@ func PromptUserChoice(sources []PackageSource, packageName string) *PackageSourc
 	return &available[choice-1]
 }
 
+// stdin is shared by every prompt. A reader buffers ahead, so with piped
+// input a reader per prompt would swallow the answers to later prompts.
+var stdin = bufio.NewReader(os.Stdin)
+
+// pendingLine receives the line being read from stdin. A read outlives a
+// cancelled prompt, and the next prompt takes its line instead of reading
+// stdin concurrently.
+var (
+	stdinMu     sync.Mutex
+	pendingLine chan string
+)
+
+// readLine reads a line from stdin, giving up when ctx is cancelled
+func readLine(ctx context.Context) (string, error) {
+	stdinMu.Lock()
+	if pendingLine == nil {
+		lines := make(chan string, 1)
+		go func() {
+			line, _ := stdin.ReadString('\n')
+			lines <- line
+		}()
+		pendingLine = lines
+	}
+	lines := pendingLine
+	stdinMu.Unlock()
+
+	select {
+	case line := <-lines:
+		stdinMu.Lock()
+		pendingLine = nil
+		stdinMu.Unlock()
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
 // getConfidenceLabel returns a label for match confidence
 func getConfidenceLabel(confidence int) string {
 	if confidence >= 90 {
@@ -470,7 +448,7 @@ func GetBestMatch(sources []PackageSource) *PackageSource {
 	}
 
 	// If best match is very high confidence and native, auto-select
-	if available[0].Manager != "flatpak" && available[0].Confidence == 100 {
+	if isNativeSource(available[0].Manager) && available[0].Confidence == 100 {
 		return &available[0]
 	}
 
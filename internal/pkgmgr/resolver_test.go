@@ -0,0 +1,50 @@
+package pkgmgr
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// useStdin makes prompts read from r for the rest of the test
+func useStdin(t *testing.T, r io.Reader) {
+	t.Helper()
+
+	previous := stdin
+	stdin = bufio.NewReader(r)
+	pendingLine = nil
+	t.Cleanup(func() {
+		stdin = previous
+		pendingLine = nil
+	})
+}
+
+func TestReadLinePiped(t *testing.T) {
+	useStdin(t, strings.NewReader("2\ny\n"))
+
+	for _, want := range []string{"2\n", "y\n", ""} {
+		if got, err := readLine(context.Background()); got != want || err != nil {
+			t.Errorf("readLine() = %q, %v, want %q", got, err, want)
+		}
+	}
+}
+
+func TestReadLineCancelled(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	useStdin(t, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := readLine(ctx); err != context.Canceled {
+		t.Fatalf("readLine() = %v, want %v", err, context.Canceled)
+	}
+
+	// The cancelled prompt's read gets the line, and hands it on
+	go w.Write([]byte("1\n"))
+	if got, err := readLine(context.Background()); got != "1\n" || err != nil {
+		t.Errorf("readLine() = %q, %v after a cancelled prompt, want %q", got, err, "1\n")
+	}
+}
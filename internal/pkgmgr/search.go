@@ -0,0 +1,106 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SearchResult is a package found by SearchAll, scored against the term
+type SearchResult struct {
+	Package
+	Confidence int `json:"confidence"` // 0-100, as calculateMatchConfidence scores it
+}
+
+// summaryConfidence scores packages that only match on their summary
+const summaryConfidence = 50
+
+// SearchAll searches every source at the same time and returns every
+// match, best first. Sources without a fast search are only asked for the
+// exact name unless thorough is set. Sources that fail are returned as
+// errors alongside whatever the others found.
+func SearchAll(ctx context.Context, term string, sources []PackageManager, thorough bool) ([]SearchResult, []error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := []SearchResult{}
+	errs := []error{}
+
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			packages, err := searchAll(ctx, term, source, thorough)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", DisplayName(source), err))
+				return
+			}
+			for _, pkg := range packages {
+				if pkg.Source == "" {
+					pkg.Source = source.Name()
+				}
+				results = append(results, SearchResult{Package: pkg, Confidence: searchConfidence(term, pkg)})
+			}
+		}()
+	}
+
+	wg.Wait()
+	rankResults(results)
+	return results, errs
+}
+
+// searchAll runs one source's search
+func searchAll(ctx context.Context, term string, source PackageManager, thorough bool) ([]Package, error) {
+	backend, _ := LookupBackend(source.Name())
+	if thorough || backend.Capabilities.Has(CapSearch) {
+		return searchPackages(ctx, source, term)
+	}
+
+	if !isAvailable(ctx, source, term) {
+		return nil, ctx.Err()
+	}
+	pkg, err := source.Info(ctx, term)
+	if err != nil {
+		return []Package{{Name: term}}, nil
+	}
+	return []Package{*pkg}, nil
+}
+
+// searchConfidence scores a result by name, falling back to its summary
+func searchConfidence(term string, pkg Package) int {
+	displayName := pkg.DisplayName
+	if displayName == "" {
+		displayName = pkg.Name
+	}
+
+	confidence := calculateMatchConfidence(term, displayName, pkg.Name)
+	if confidence == 0 && strings.Contains(strings.ToLower(pkg.Summary), strings.ToLower(term)) {
+		confidence = summaryConfidence
+	}
+	return confidence
+}
+
+// rankResults sorts by confidence, then native packages before add-on
+// sources, then by name
+func rankResults(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if native := isNativeSource(a.Source); native != isNativeSource(b.Source) {
+			return native
+		}
+		return a.Name < b.Name
+	})
+}
+
+// Label describes how well the result matched, e.g. "exact" or "weak"
+func (r SearchResult) Label() string {
+	return getConfidenceLabel(r.Confidence)
+}
@@ -1,13 +1,14 @@
 package pkgmgr
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
 )
 
 // RunInit detects package manager and saves configuration
-func RunInit() error {
+func RunInit(ctx context.Context) error {
 	fmt.Println("🚀 Initializing LazyLinux...")
 	fmt.Println()
 
@@ -18,8 +19,8 @@ func RunInit() error {
 		return fmt.Errorf("detection failed: %v", err)
 	}
 
-	pmName := getPackageManagerName(pm)
-	fmt.Printf("✅ Detected: %s\n", pmName)
+	pmName := pm.Name()
+	fmt.Printf("✅ Detected: %s\n", DisplayName(pm))
 	fmt.Println()
 
 	// Get user preferences with auto-detection
@@ -27,7 +28,7 @@ func RunInit() error {
 
 	// Enable the selected sources
 	fmt.Println("\n⏳ Setting up package sources...")
-	if err := enableSources(prefs); err != nil {
+	if err := enableSources(ctx, prefs); err != nil {
 		fmt.Printf("⚠️  Some installations failed, but continuing...\n")
 	}
 
@@ -47,26 +48,25 @@ func RunInit() error {
 	return nil
 }
 
-func getPackageManagerName(pm PackageManager) string {
-	switch pm.(type) {
-	case *DNF:
-		return "dnf"
-	case *APT:
-		return "apt"
-	case *Pacman:
-		return "pacman"
-	default:
-		return "unknown"
-	}
-}
-
+// saveSourcePreferences records the detected package manager and sources.
+// Re-running init keeps everything else in an existing config, and leaves
+// one it can't parse alone rather than replace it.
 func saveSourcePreferences(pmName string) error {
-	cfg := &config.Config{
-		PackageManager: pmName,
-		FlatpakEnabled: isFlatpakInstalled(),
-		SnapEnabled:    isSnapInstalled(),
-		RPMEnabled:     isRPMInstalled(),
+	cfg := &config.Config{}
+	if config.ConfigExists() {
+		existing, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("%v; fix or remove %s and run init again", err, config.GetConfigPath())
+		}
+		cfg = existing
 	}
 
+	cfg.PackageManager = pmName
+	cfg.FlatpakEnabled = isFlatpakInstalled()
+	cfg.SnapEnabled = isSnapInstalled()
+	cfg.RPMEnabled = isRPMInstalled()
+	cfg.AUREnabled = pmName == "pacman" && isAURHelperInstalled()
+	cfg.NixEnabled = isNixInstalled()
+
 	return config.SaveConfig(cfg)
 }
@@ -0,0 +1,86 @@
+package pkgmgr
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+func TestSaveSourcePreferences(t *testing.T) {
+	fake := useFixtures(t)
+	fake.AddPath("flatpak", "paru")
+	t.Setenv("HOME", t.TempDir())
+
+	existing := &config.Config{
+		PackageManager:  "dnf",
+		SnapEnabled:     true,
+		SnapChannel:     "beta",
+		FlatpakScope:    "user",
+		Escalation:      "doas",
+		DisabledPlugins: []string{"conda"},
+		Timeouts:        config.Timeouts{Install: time.Hour},
+		CacheTTL:        6 * time.Hour,
+		Hooks:           map[string][]string{"pre-update": {"snapper create"}},
+		Aliases:         map[string]config.Alias{"browser": {Packages: []string{"org.mozilla.firefox"}, Source: "flatpak"}},
+	}
+	if err := config.SaveConfig(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveSourcePreferences("pacman"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *existing
+	want.PackageManager = "pacman"
+	want.FlatpakEnabled, want.SnapEnabled, want.AUREnabled = true, false, true
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("config after init =\n%+v\nwant\n%+v", *got, want)
+	}
+}
+
+func TestSaveSourcePreferencesNewConfig(t *testing.T) {
+	fake := useFixtures(t)
+	fake.AddPath("snap")
+	t.Setenv("HOME", t.TempDir())
+
+	if err := saveSourcePreferences("apt"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (config.Config{PackageManager: "apt", SnapEnabled: true}); !reflect.DeepEqual(*got, want) {
+		t.Errorf("config after init = %+v, want %+v", *got, want)
+	}
+}
+
+func TestSaveSourcePreferencesInvalidConfig(t *testing.T) {
+	useFixtures(t)
+	t.Setenv("HOME", t.TempDir())
+
+	path := config.GetConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("aliases: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveSourcePreferences("dnf"); err == nil {
+		t.Error("saveSourcePreferences() replaced a config it couldn't parse")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "aliases: [\n" {
+		t.Errorf("config = %q, want it left alone", data)
+	}
+}
@@ -0,0 +1,405 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+// Snap represents the Snap package manager
+type Snap struct {
+	Channel string // "stable", "candidate", "beta", "edge" or "<track>/<risk>"
+}
+
+// NewSnap creates a new Snap instance tracking the given channel
+func NewSnap(channel string) *Snap {
+	if channel == "" {
+		channel = "stable"
+	}
+	return &Snap{Channel: channel}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "snap",
+		DisplayName:  "Snap",
+		Icon:         "🧩",
+		Priority:     20,
+		Capabilities: CapSearch | CapChannels,
+		Detect:       func() bool { return isSnapInstalled() },
+		Enabled:      func(cfg *config.Config) bool { return cfg.SnapEnabled },
+		New:          func(cfg *config.Config) PackageManager { return NewSnap(snapChannel(cfg)) },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (s *Snap) Name() string {
+	return "snap"
+}
+
+// snapChannel reads the configured channel, tolerating a nil config
+func snapChannel(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.SnapChannel
+}
+
+// Install installs packages via Snap from the configured channel
+func (s *Snap) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Snap command: sudo snap install --channel=<channel> [--classic] <package>
+	// Classic confinement is decided per snap, so install them one at a time
+	for _, pkg := range packages {
+		args := []string{"install", "--channel=" + s.Channel}
+		if s.isClassicSnap(ctx, pkg) {
+			args = append(args, "--classic")
+		}
+		args = append(args, pkg)
+
+		cmd := privilegedCommand(ctx, "snap", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to install %s: %w", pkg, err)
+		}
+	}
+
+	return nil
+}
+
+// Remove uninstalls snaps
+func (s *Snap) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Snap command: sudo snap remove <packages>
+	args := append([]string{"remove"}, packages...)
+	cmd := privilegedCommand(ctx, "snap", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Update refreshes all installed snaps
+func (s *Snap) Update(ctx context.Context) error {
+	// Snap command: sudo snap refresh
+	cmd := privilegedCommand(ctx, "snap", "refresh")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Clean removes disabled snap revisions left behind by refreshes
+func (s *Snap) Clean(ctx context.Context) error {
+	fmt.Println("🧹 Removing disabled snap revisions...")
+
+	revisions, err := disabledSnapRevisions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range revisions {
+		removeCmd := privilegedCommand(ctx, "snap", "remove", rev.Name, "--revision="+rev.Revision)
+		removeCmd.Stdout = os.Stdout
+		removeCmd.Stderr = os.Stderr
+		if err := removeCmd.Run(); err != nil {
+			return fmt.Errorf("failed to remove %s revision %s: %w", rev.Name, rev.Revision, err)
+		}
+	}
+
+	if len(revisions) == 0 {
+		fmt.Println("✨ No disabled revisions found")
+	}
+
+	return nil
+}
+
+// snapRevision is one installed revision of a snap
+type snapRevision struct {
+	Name     string
+	Version  string
+	Revision string
+}
+
+// disabledSnapRevisions lists revisions kept around after a refresh
+func disabledSnapRevisions(ctx context.Context) ([]snapRevision, error) {
+	// snap list --all output: "Name  Version  Rev  Tracking  Publisher  Notes"
+	cmd := newCommand(ctx, "snap", "list", "--all")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []snapRevision
+	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 6 || parts[0] == "Name" {
+			continue
+		}
+		if !strings.Contains(parts[len(parts)-1], "disabled") {
+			continue
+		}
+		revisions = append(revisions, snapRevision{Name: parts[0], Version: parts[1], Revision: parts[2]})
+	}
+
+	return revisions, nil
+}
+
+// Simulate previews an operation from the Snap Store's metadata, since
+// snap has no dry run. Refreshes come from "snap refresh --list".
+func (s *Snap) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	set := &ChangeSet{}
+
+	switch op {
+	case OpInstall, OpRemove:
+		for _, pkg := range packages {
+			info, err := s.Info(ctx, pkg)
+			if err != nil {
+				return nil, err
+			}
+			if op == OpRemove {
+				if !info.Installed {
+					return nil, fmt.Errorf("%w: %s is not installed", ErrPackageNotFound, pkg)
+				}
+				set.Add(ActionRemove, pkg, info.Version, info.InstalledSize)
+			} else if !info.Installed {
+				set.Add(ActionInstall, pkg, info.Version, 0)
+			}
+		}
+
+	case OpUpdate:
+		// Exits 0 with "All snaps up to date." on stderr when there's nothing
+		cmd := newCommand(ctx, "snap", "refresh", "--list")
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, err
+		}
+		set = parseSnapRefreshList(string(output))
+
+	case OpClean:
+		revisions, err := disabledSnapRevisions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, rev := range revisions {
+			set.Add(ActionRemove, rev.Name, fmt.Sprintf("%s (rev %s)", rev.Version, rev.Revision), 0)
+		}
+	}
+
+	return set, nil
+}
+
+// parseSnapRefreshList parses "snap refresh --list" output:
+//
+//	Name     Version  Rev   Size   Publisher  Notes
+//	firefox  129.0-2  4793  261MB  mozilla✓   -
+func parseSnapRefreshList(output string) *ChangeSet {
+	set := &ChangeSet{}
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 4 || parts[0] == "Name" {
+			continue
+		}
+
+		size := parseHumanSize(parts[3])
+		set.Add(ActionUpgrade, parts[0], parts[1], size)
+		set.DownloadSize += size
+	}
+
+	return set
+}
+
+// List lists all installed snaps
+func (s *Snap) List(ctx context.Context) ([]Package, error) {
+	// snap list
+	cmd := newCommand(ctx, "snap", "list")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSnapList(string(output)), nil
+}
+
+// parseSnapList parses "snap list" output:
+//
+//	Name     Version   Rev    Tracking       Publisher   Notes
+//	firefox  128.0-1   4650   latest/stable  mozilla✓    -
+func parseSnapList(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 4 || parts[0] == "Name" {
+			continue
+		}
+
+		pkg := Package{
+			Name:       parts[0],
+			Version:    parts[1],
+			Repository: parts[3], // Tracked channel
+			Source:     "snap",
+			Reason:     ReasonExplicit,
+		}
+		// Snaps such as core and snapd come in as bases, not on request
+		if len(parts) >= 6 && (strings.Contains(parts[5], "base") || strings.Contains(parts[5], "core")) {
+			pkg.Reason = ReasonDependency
+		}
+
+		results = append(results, pkg)
+	}
+
+	return results
+}
+
+// isClassicSnap checks whether a snap requires classic confinement on
+// the configured channel
+func (s *Snap) isClassicSnap(ctx context.Context, name string) bool {
+	cmd := newCommand(ctx, "snap", "info", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+
+	return isClassicChannel(string(output), s.storeChannel())
+}
+
+// isClassicChannel reads whether channel is classic from "snap info"
+// output. Channel lines end with "classic" for classic snaps, and "↑"
+// means the same release as the channel above:
+//
+//	channels:
+//	  latest/stable:    1.85.0 2024-01-01 (151) 321MB classic
+//	  latest/candidate: ↑
+func isClassicChannel(output, channel string) bool {
+	classic := false
+	lines := strings.SplitSeq(output, "\n")
+
+	for line := range lines {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found || !strings.Contains(key, "/") {
+			continue
+		}
+
+		fields := strings.Fields(value)
+		if len(fields) != 1 || fields[0] != "↑" {
+			classic = len(fields) > 0 && fields[len(fields)-1] == "classic"
+		}
+		if key == channel {
+			return classic
+		}
+	}
+
+	return false
+}
+
+// storeChannel returns the configured channel with its track, e.g.
+// "latest/stable" for "stable"
+func (s *Snap) storeChannel() string {
+	if !strings.Contains(s.Channel, "/") {
+		return "latest/" + s.Channel
+	}
+	return s.Channel
+}
+
+// Search searches the Snap Store for term
+func (s *Snap) Search(ctx context.Context, term string) ([]Package, error) {
+	// snap find output: "Name  Version  Publisher  Notes  Summary"
+	cmd := newCommand(ctx, "snap", "find", term)
+	output, err := cmd.Output()
+	if err != nil {
+		// snap find exits 1 when nothing matches
+		if len(output) == 0 {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(string(output)), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 2 || parts[0] == "Name" {
+			continue
+		}
+
+		pkg := Package{
+			Name:    parts[0],
+			Version: parts[1],
+			Source:  "snap",
+		}
+		if len(parts) > 4 {
+			pkg.Summary = strings.Join(parts[4:], " ")
+		}
+
+		results = append(results, pkg)
+	}
+
+	return results, nil
+}
+
+// Info returns details for a snap, with the version from the configured
+// channel unless it is already installed
+func (s *Snap) Info(ctx context.Context, name string) (*Package, error) {
+	cmd := newCommand(ctx, "snap", "info", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	fields := parseKeyValue(string(output))
+	pkg := &Package{
+		Name:    fields["name"],
+		Summary: fields["summary"],
+		URL:     fields["store-url"],
+		Source:  "snap",
+	}
+
+	// installed:  128.0-1  (4650) 250MB -
+	if installed := strings.Fields(fields["installed"]); len(installed) > 0 {
+		pkg.Installed = true
+		pkg.Version = installed[0]
+		pkg.Repository = fields["tracking"]
+		if len(installed) > 2 {
+			pkg.InstalledSize = parseHumanSize(installed[2])
+		}
+		return pkg, nil
+	}
+
+	// latest/stable:  128.0-1  2024-07-09 (4650) 250MB -
+	channel := s.storeChannel()
+	if release := strings.Fields(fields[channel]); len(release) > 0 {
+		pkg.Version = release[0]
+		pkg.Repository = channel
+	}
+
+	return pkg, nil
+}
+
+// IsAvailable checks the Snap Store for an exact snap name
+func (s *Snap) IsAvailable(ctx context.Context, name string) bool {
+	cmd := newCommand(ctx, "snap", "info", name)
+	return cmd.Run() == nil
+}
+
+// IsInstalled checks whether a snap is installed
+func (s *Snap) IsInstalled(ctx context.Context, name string) bool {
+	cmd := newCommand(ctx, "snap", "list", name)
+	return cmd.Run() == nil
+}
@@ -0,0 +1,218 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSnapInstallConfinement(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		snap    string
+		want    []string
+	}{
+		{
+			name:    "classic snap",
+			channel: "stable",
+			snap:    "code",
+			want:    []string{"sudo", "snap", "install", "--channel=stable", "--classic", "code"},
+		},
+		{
+			name:    "classic on another channel only",
+			channel: "stable",
+			snap:    "lxd",
+			want:    []string{"sudo", "snap", "install", "--channel=stable", "lxd"},
+		},
+		{
+			name:    "classic on the configured channel",
+			channel: "edge",
+			snap:    "lxd",
+			want:    []string{"sudo", "snap", "install", "--channel=edge", "--classic", "lxd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFixtures(t, "snap")
+
+			if err := NewSnap(tt.channel).Install(context.Background(), tt.snap); err != nil {
+				t.Fatal(err)
+			}
+			calls := callArgs(fake)
+			if got := calls[len(calls)-1]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Install() ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsClassicChannel(t *testing.T) {
+	const output = `channels:
+  latest/stable:    6.1-c14927a 2024-10-02 (30130) 110MB -
+  latest/candidate: ↑
+  latest/beta:      git-1 2024-10-10 (30200) 110MB classic
+  latest/edge:      ↑
+  5.21/stable:      5.21.2-2f4ba6b 2024-08-08 (29619) 104MB -
+installed:          6.1-c14927a            (30130) 110MB classic
+`
+
+	tests := []struct {
+		channel string
+		want    bool
+	}{
+		{channel: "latest/stable", want: false},
+		{channel: "latest/candidate", want: false},
+		{channel: "latest/beta", want: true},
+		{channel: "latest/edge", want: true}, // ↑ follows beta
+		{channel: "5.21/stable", want: false},
+		{channel: "6.0/stable", want: false}, // Not offered
+	}
+
+	for _, tt := range tests {
+		if got := isClassicChannel(output, tt.channel); got != tt.want {
+			t.Errorf("isClassicChannel(%q) = %v, want %v", tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestSnapSearch(t *testing.T) {
+	useFixtures(t, "snap")
+
+	tests := []struct {
+		term string
+		want []Package
+	}{
+		{
+			term: "code",
+			want: []Package{
+				{Name: "code", Summary: "Code editing. Redefined.", Version: "dfd34e8a", Source: "snap"},
+				{Name: "code-insiders", Summary: "Code editing. Redefined.", Version: "f1f6c4b5", Source: "snap"},
+				{Name: "codium", Summary: "Code editing. Redefined.", Version: "1.94.2.24286", Source: "snap"},
+			},
+		},
+		{
+			term: "doesnotexist",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			got, err := NewSnap("stable").Search(context.Background(), tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) =\n%+v\nwant\n%+v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		snap    string
+		want    *Package
+	}{
+		{
+			name:    "not installed",
+			channel: "stable",
+			snap:    "code",
+			want:    &Package{Name: "code", Summary: "Code editing. Redefined.", URL: "https://snapcraft.io/code", Version: "dfd34e8a", Repository: "latest/stable", Source: "snap"},
+		},
+		{
+			name:    "not installed on another channel",
+			channel: "edge",
+			snap:    "code",
+			want:    &Package{Name: "code", Summary: "Code editing. Redefined.", URL: "https://snapcraft.io/code", Version: "6f47d5d5", Repository: "latest/edge", Source: "snap"},
+		},
+		{
+			name:    "installed",
+			channel: "edge",
+			snap:    "firefox",
+			want:    &Package{Name: "firefox", Summary: "Mozilla Firefox web browser", URL: "https://snapcraft.io/firefox", Version: "131.0.3-1", Repository: "latest/stable", Source: "snap", InstalledSize: 282_000_000, Installed: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFixtures(t, "snap")
+
+			got, err := NewSnap(tt.channel).Info(context.Background(), tt.snap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Info(%q) =\n%+v\nwant\n%+v", tt.snap, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		useFixtures(t, "snap")
+
+		if _, err := NewSnap("stable").Info(context.Background(), "doesnotexist"); !errors.Is(err, ErrPackageNotFound) {
+			t.Errorf("Info() = %v, want %v", err, ErrPackageNotFound)
+		}
+	})
+}
+
+func TestSnapList(t *testing.T) {
+	useFixtures(t, "snap")
+
+	packages, err := NewSnap("stable").List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "bare", Version: "1.0", Repository: "latest/stable", Source: "snap", Reason: ReasonDependency},
+		{Name: "core22", Version: "20240904", Repository: "latest/stable", Source: "snap", Reason: ReasonDependency},
+		{Name: "firefox", Version: "131.0.3-1", Repository: "latest/stable", Source: "snap", Reason: ReasonExplicit},
+		{Name: "snapd", Version: "2.65.3", Repository: "latest/stable", Source: "snap", Reason: ReasonExplicit},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestParseSnapRefreshList(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *ChangeSet
+	}{
+		{
+			name:   "up to date",
+			output: "",
+			want:   &ChangeSet{},
+		},
+		{
+			name: "refreshes",
+			output: `Name     Version   Rev   Size   Publisher   Notes
+firefox  132.0-1   5187  261MB  mozilla✓    -
+core22   20241001  1663  77MB   canonical✓  base
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionUpgrade, Name: "firefox", Version: "132.0-1", Size: 261_000_000},
+					{Action: ActionUpgrade, Name: "core22", Version: "20241001", Size: 77_000_000},
+				},
+				DownloadSize: 338_000_000,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSnapRefreshList(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSnapRefreshList() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
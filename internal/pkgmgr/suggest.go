@@ -0,0 +1,161 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// suggestionConfidence is the lowest score worth suggesting; typos score
+// below what resolving accepts, so they are only ever offered
+const suggestionConfidence = 60
+
+// SuggestPackages looks for packages close to a name nothing matched, such
+// as "firefox" for "firefx". Every searchable source is asked for names
+// sharing the first few letters, which are then scored against the name.
+func SuggestPackages(ctx context.Context, packageName string, nativePM PackageManager, extraSources []PackageManager) []PackageSource {
+	letters := []rune(packageName)
+	if len(letters) < 4 {
+		return nil // Too short for typos to mean anything
+	}
+	prefix := string(letters[:3])
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	suggestions := []PackageSource{}
+
+	for _, source := range append([]PackageManager{nativePM}, extraSources...) {
+		backend, _ := LookupBackend(source.Name())
+		if !backend.Capabilities.Has(CapSearch) {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			packages, err := searchPackages(ctx, source, prefix)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, pkg := range packages {
+				displayName := pkg.DisplayName
+				if displayName == "" {
+					displayName = pkg.Name
+				}
+
+				confidence := suggestionScore(packageName, displayName, pkg.Name)
+				if confidence < suggestionConfidence {
+					continue
+				}
+				suggestions = append(suggestions, PackageSource{
+					Manager:     source.Name(),
+					PackageName: pkg.Name,
+					Available:   true,
+					Confidence:  confidence,
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return topMatches(suggestions)
+}
+
+// PromptSuggestion offers suggestions for a package that wasn't found and
+// returns the one picked, or nil if there are none or the user skips
+func PromptSuggestion(ctx context.Context, packageName string, suggestions []PackageSource) *PackageSource {
+	if len(suggestions) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n❓ '%s' not found. Did you mean:\n", packageName)
+	for i, src := range suggestions {
+		fmt.Printf("  [%d] %-10s - %s\n", i+1, src.Manager, src.PackageName)
+	}
+
+	fmt.Print("\nChoose one, or press Enter to skip: ")
+	input, err := readLine(ctx)
+	if err != nil {
+		fmt.Println()
+		return nil
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || choice < 1 || choice > len(suggestions) {
+		return nil
+	}
+	return &suggestions[choice-1]
+}
+
+// suggestionScore scores a package as a suggestion for term: how well it
+// matches, or how close a typo term is of its name or one of the words in
+// its ID, "firefx" → "firefox" and "spotfy" → "com.spotify.Client"
+func suggestionScore(term, displayName, name string) int {
+	term = strings.ToLower(term)
+
+	typo := typoConfidence(term, strings.ToLower(displayName))
+	for _, part := range strings.FieldsFunc(strings.ToLower(name), isNameSeparator) {
+		// A typo of a part counts a little less than one of the whole name
+		if confidence := typoConfidence(term, part); confidence > 0 {
+			typo = max(typo, confidence-2)
+		}
+	}
+
+	return max(calculateMatchConfidence(term, displayName, name), typo)
+}
+
+// typoConfidence scores a near miss by how many single-letter edits turn
+// term into word, allowing two for longer words
+func typoConfidence(term, word string) int {
+	length := len([]rune(term))
+	if length < 4 {
+		return 0
+	}
+
+	switch distance := editDistance(term, word); {
+	case distance == 1:
+		return 72
+	case distance == 2 && length >= 6:
+		return 65
+	}
+	return 0
+}
+
+// editDistance counts the single-letter insertions, deletions,
+// substitutions and swaps of neighbours that turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// d[i][j] is the distance between the first i runes of a and j of b
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			// "htpo" → "htop" is one slip, not two substitutions
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,95 @@
+package pkgmgr
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"htop", "htop", 0},
+		{"firefx", "firefox", 1},   // Insertion
+		{"firefoxx", "firefox", 1}, // Deletion
+		{"neovin", "neovim", 1},    // Substitution
+		{"htpo", "htop", 1},        // Swapped neighbours
+		{"thunderbrid", "thunderbird", 1},
+		{"gimpp", "gmip", 2},
+		{"", "vim", 3},
+		{"vim", "emacs", 5},
+		{"café", "cafe", 1}, // Counted in letters, not bytes
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestTypoConfidence(t *testing.T) {
+	tests := []struct {
+		term, word string
+		want       int
+	}{
+		{"firefx", "firefox", 72},
+		{"htpo", "htop", 72},
+		{"thundrbrd", "thunderbird", 65},
+		{"gmip", "gimpx", 0},      // Two edits need a longer word
+		{"vmi", "vim", 0},         // Too short to call a typo
+		{"firefox", "firefox", 0}, // Not a typo at all
+		{"chromium", "firefox", 0},
+	}
+
+	for _, tt := range tests {
+		if got := typoConfidence(tt.term, tt.word); got != tt.want {
+			t.Errorf("typoConfidence(%q, %q) = %d, want %d", tt.term, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestionScore(t *testing.T) {
+	tests := []struct {
+		term, displayName, name string
+		want                    int
+	}{
+		{"firefx", "Firefox", "org.mozilla.firefox", 72},
+		{"spotfy", "Spotify", "com.spotify.Client", 72},
+		{"spotfy", "Music", "com.spotify.Client", 70}, // Only a word of the ID is close
+		{"neovin", "neovim", "neovim", 72},
+		{"firefox", "Firefox", "org.mozilla.firefox", 100},
+		{"chromium", "Firefox", "org.mozilla.firefox", 0},
+	}
+
+	for _, tt := range tests {
+		if got := suggestionScore(tt.term, tt.displayName, tt.name); got != tt.want {
+			t.Errorf("suggestionScore(%q, %q, %q) = %d, want %d", tt.term, tt.displayName, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateMatchConfidence(t *testing.T) {
+	tests := []struct {
+		term, appName, appID string
+		want                 int
+	}{
+		{"firefox", "Firefox", "org.mozilla.firefox", 100},
+		{"org.mozilla.firefox", "Firefox", "org.mozilla.firefox", 95},
+		{"fire", "Firefox", "org.mozilla.firefox", 90},
+		{"fox", "Firefox", "org.mozilla.firefox", 85},
+		{"zen", "Zen", "org.zen_browser.zen", 100},
+		{"browser", "Zen", "org.zen_browser.zen", 85},
+		{"spot", "Music", "com.spotify.Client", 78},
+		// Typos only count as suggestions, never when resolving or ranking
+		{"firefx", "Firefox", "org.mozilla.firefox", 0},
+		{"spotfy", "Spotify", "com.spotify.Client", 0},
+	}
+
+	for _, tt := range tests {
+		if got := calculateMatchConfidence(tt.term, tt.appName, tt.appID); got != tt.want {
+			t.Errorf("calculateMatchConfidence(%q, %q, %q) = %d, want %d", tt.term, tt.appName, tt.appID, got, tt.want)
+		}
+	}
+}
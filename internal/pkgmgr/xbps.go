@@ -0,0 +1,333 @@
+package pkgmgr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+type XBPS struct{}
+
+func NewXBPS() *XBPS {
+	return &XBPS{}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "xbps",
+		DisplayName:  "XBPS",
+		Icon:         "📦",
+		Native:       true,
+		Priority:     60,
+		Capabilities: CapSearch | CapOrphans | CapInstallReason,
+		Detect:       func() bool { return commandExists("xbps-install") },
+		New:          func(cfg *config.Config) PackageManager { return NewXBPS() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (x *XBPS) Name() string {
+	return "xbps"
+}
+
+func (x *XBPS) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// XBPS command: sudo xbps-install -Sy <packages>
+	args := append([]string{"-Sy"}, packages...)
+	cmd := privilegedCommand(ctx, "xbps-install", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (x *XBPS) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// XBPS command: sudo xbps-remove -Ry <packages>
+	// -R = also remove dependencies that are no longer needed
+	args := append([]string{"-Ry"}, packages...)
+	cmd := privilegedCommand(ctx, "xbps-remove", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (x *XBPS) Update(ctx context.Context) error {
+	// xbps refuses to upgrade anything else while xbps itself is outdated,
+	// so update it first: sudo xbps-install -Suy xbps
+	selfCmd := privilegedCommand(ctx, "xbps-install", "-Suy", "xbps")
+	selfCmd.Stdout = os.Stdout
+	selfCmd.Stderr = os.Stderr
+	err := selfCmd.Run()
+	if err != nil {
+		return err
+	}
+
+	// Then the full system: sudo xbps-install -Suy
+	cmd := privilegedCommand(ctx, "xbps-install", "-Suy")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (x *XBPS) Clean(ctx context.Context) error {
+	// Clean package cache (keep only current versions)
+	fmt.Println("🧹 Cleaning package cache...")
+	cleanCmd := privilegedCommand(ctx, "xbps-remove", "-Oy")
+	cleanCmd.Stdout = os.Stdout
+	cleanCmd.Stderr = os.Stderr
+	err := cleanCmd.Run()
+	if err != nil {
+		return err
+	}
+
+	// Remove orphaned packages
+	fmt.Println("🗑️  Removing orphaned packages...")
+	orphanCmd := privilegedCommand(ctx, "xbps-remove", "-oy")
+	orphanCmd.Stdout = os.Stdout
+	orphanCmd.Stderr = os.Stderr
+	return orphanCmd.Run()
+}
+
+// Simulate previews an operation with the xbps tools' dry-run mode
+func (x *XBPS) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	var cmd *Command
+	switch op {
+	case OpInstall:
+		cmd = newCommand(ctx, "xbps-install", append([]string{"-n"}, packages...)...)
+	case OpRemove:
+		cmd = newCommand(ctx, "xbps-remove", append([]string{"-Rn"}, packages...)...)
+	case OpUpdate:
+		cmd = newCommand(ctx, "xbps-install", "-un")
+	case OpClean:
+		cmd = newCommand(ctx, "xbps-remove", "-on")
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseXBPSDryRun(string(output)), nil
+}
+
+// parseXBPSDryRun parses dry-run output, one transaction step per line:
+//
+//	vim-9.1.0_1 install x86_64 https://repo-default.voidlinux.org/current 3811020 1234567
+//
+// Sizes are the installed size and the download size in bytes.
+func parseXBPSDryRun(output string) *ChangeSet {
+	set := &ChangeSet{}
+	actions := map[string]ChangeAction{
+		"install":   ActionInstall,
+		"update":    ActionUpgrade,
+		"downgrade": ActionDowngrade,
+		"reinstall": ActionReinstall,
+		"remove":    ActionRemove,
+	}
+
+	lines := strings.SplitSeq(output, "\n")
+	for line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		action, ok := actions[fields[1]]
+		if !ok {
+			continue // configure, hold and download change nothing new
+		}
+
+		// Same "version-revision" form List uses
+		name, version := splitXBPSPkgver(fields[0])
+		version, revision := splitXBPSRevision(version)
+		if revision != "" {
+			version += "-" + revision
+		}
+
+		var size int64
+		if action == ActionRemove && len(fields) >= 5 {
+			size, _ = strconv.ParseInt(fields[4], 10, 64)
+		} else if len(fields) >= 6 {
+			size, _ = strconv.ParseInt(fields[5], 10, 64)
+			set.DownloadSize += size
+		}
+		set.Add(action, name, version, size)
+	}
+
+	return set
+}
+
+// List lists all installed packages
+func (x *XBPS) List(ctx context.Context) ([]Package, error) {
+	// xbps-query -l output: "ii name-version_revision  short description"
+	cmd := newCommand(ctx, "xbps-query", "-l")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// xbps-query -m lists the pkgvers that were installed manually
+	manualCmd := newCommand(ctx, "xbps-query", "-m")
+	manual, _ := manualCmd.Output()
+
+	return parseXBPSList(string(output), string(manual)), nil
+}
+
+// parseXBPSList parses "xbps-query -l" output, using the "xbps-query -m"
+// output to tell explicit installs from dependencies
+func parseXBPSList(output, manual string) []Package {
+	explicit := map[string]bool{}
+	for pkgver := range strings.FieldsSeq(manual) {
+		name, _ := splitXBPSPkgver(pkgver)
+		explicit[name] = true
+	}
+
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+
+		name, version := splitXBPSPkgver(parts[1])
+		version, release := splitXBPSRevision(version)
+
+		reason := ReasonDependency
+		if explicit[name] {
+			reason = ReasonExplicit
+		}
+
+		results = append(results, Package{
+			Name:    name,
+			Version: version,
+			Release: release,
+			Source:  "xbps",
+			Reason:  reason,
+		})
+	}
+
+	return results
+}
+
+// splitXBPSPkgver splits an xbps pkgver such as "foo-bar-1.2.3_1" into
+// its name and version. Versions never contain a dash, so the last dash
+// is always the separator.
+func splitXBPSPkgver(pkgver string) (string, string) {
+	i := strings.LastIndex(pkgver, "-")
+	if i <= 0 {
+		return pkgver, ""
+	}
+	return pkgver[:i], pkgver[i+1:]
+}
+
+// splitXBPSRevision splits "1.2.3_1" into the version and revision
+func splitXBPSRevision(version string) (string, string) {
+	i := strings.LastIndex(version, "_")
+	if i <= 0 {
+		return version, ""
+	}
+	return version[:i], version[i+1:]
+}
+
+// Search searches the repositories for term
+func (x *XBPS) Search(ctx context.Context, term string) ([]Package, error) {
+	// xbps-query -Rs <term> output: "[*] name-version_revision  description"
+	cmd := newCommand(ctx, "xbps-query", "-Rs", term)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search packages: %w", err)
+	}
+
+	return parseXBPSSearch(string(output)), nil
+}
+
+// Info returns details for a package from the repositories
+func (x *XBPS) Info(ctx context.Context, name string) (*Package, error) {
+	// xbps-query -R <package> prints "key: value" properties
+	cmd := newCommand(ctx, "xbps-query", "-R", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	fields := parseKeyValue(string(output))
+	pkgName, version := splitXBPSPkgver(fields["pkgver"])
+	version, release := splitXBPSRevision(version)
+
+	return &Package{
+		Name:          pkgName,
+		Summary:       fields["short_desc"],
+		URL:           fields["homepage"],
+		Version:       version,
+		Release:       release,
+		Arch:          fields["architecture"],
+		Repository:    fields["repository"],
+		Source:        "xbps",
+		InstalledSize: parseHumanSize(fields["installed_size"]),
+		Installed:     x.IsInstalled(ctx, name),
+	}, nil
+}
+
+// IsAvailable checks the repositories for an exact package name
+func (x *XBPS) IsAvailable(ctx context.Context, name string) bool {
+	packages, err := x.Search(ctx, name)
+	if err != nil {
+		return false
+	}
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInstalled checks whether a package is installed
+func (x *XBPS) IsInstalled(ctx context.Context, name string) bool {
+	// xbps-query <package> (exits 0 only when installed)
+	cmd := newCommand(ctx, "xbps-query", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// parseXBPSSearch parses "xbps-query -Rs" output
+func parseXBPSSearch(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+
+		name, version := splitXBPSPkgver(parts[1])
+		version, release := splitXBPSRevision(version)
+
+		results = append(results, Package{
+			Name:      name,
+			Summary:   strings.Join(parts[2:], " "),
+			Version:   version,
+			Release:   release,
+			Source:    "xbps",
+			Installed: parts[0] == "[*]",
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,140 @@
+package pkgmgr
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestXBPSList(t *testing.T) {
+	useFixtures(t, "xbps")
+
+	packages, err := NewXBPS().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "base-system", Version: "0.114", Release: "1", Source: "xbps", Reason: ReasonExplicit},
+		{Name: "vim", Version: "9.1.0707", Release: "1", Source: "xbps", Reason: ReasonExplicit},
+		{Name: "vim-common", Version: "9.1.0707", Release: "1", Source: "xbps", Reason: ReasonDependency},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("List() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestXBPSSearch(t *testing.T) {
+	useFixtures(t, "xbps")
+
+	packages, err := NewXBPS().Search(context.Background(), "vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "neovim", Summary: "Fork of Vim aiming to improve user experience, plugins and GUIs", Version: "0.10.1", Release: "1", Source: "xbps"},
+		{Name: "vim", Summary: "Vim editor (vi clone)", Version: "9.1.0707", Release: "1", Source: "xbps", Installed: true},
+		{Name: "vim-common", Summary: "Vim editor (vi clone) - common files", Version: "9.1.0707", Release: "1", Source: "xbps", Installed: true},
+	}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("Search() =\n%+v\nwant\n%+v", packages, want)
+	}
+}
+
+func TestXBPSInfo(t *testing.T) {
+	useFixtures(t, "xbps")
+
+	pkg, err := NewXBPS().Info(context.Background(), "vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Package{
+		Name:          "vim",
+		Summary:       "Vim editor (vi clone)",
+		URL:           "https://www.vim.org",
+		Version:       "9.1.0707",
+		Release:       "1",
+		Arch:          "x86_64",
+		Repository:    "https://repo-default.voidlinux.org/current",
+		Source:        "xbps",
+		InstalledSize: 3_711_000,
+		Installed:     true,
+	}
+	if !reflect.DeepEqual(pkg, want) {
+		t.Errorf("Info() =\n%+v\nwant\n%+v", pkg, want)
+	}
+
+	if _, err := NewXBPS().Info(context.Background(), "vmi"); !errors.Is(err, ErrPackageNotFound) {
+		t.Errorf("Info(%q) = %v, want %v", "vmi", err, ErrPackageNotFound)
+	}
+}
+
+func TestSplitXBPSPkgver(t *testing.T) {
+	tests := []struct {
+		pkgver                  string
+		name, version, revision string
+	}{
+		{"vim-9.1.0707_1", "vim", "9.1.0707", "1"},
+		{"python3-pip-24.0_2", "python3-pip", "24.0", "2"},
+		{"font-adobe-100dpi-1.0.4_1", "font-adobe-100dpi", "1.0.4", "1"},
+		{"vim", "vim", "", ""},
+	}
+
+	for _, tt := range tests {
+		name, version := splitXBPSPkgver(tt.pkgver)
+		version, revision := splitXBPSRevision(version)
+		if name != tt.name || version != tt.version || revision != tt.revision {
+			t.Errorf("%q split into %q, %q, %q, want %q, %q, %q", tt.pkgver, name, version, revision, tt.name, tt.version, tt.revision)
+		}
+	}
+}
+
+func TestParseXBPSDryRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   *ChangeSet
+	}{
+		{
+			name:   "nothing to do",
+			output: "",
+			want:   &ChangeSet{},
+		},
+		{
+			name: "install and update",
+			output: `libsodium-1.0.20_1 install x86_64 https://repo-default.voidlinux.org/current 462848 180456
+vim-9.1.0707_1 install x86_64 https://repo-default.voidlinux.org/current 3801088 1834496
+curl-8.10.1_1 update x86_64 https://repo-default.voidlinux.org/current 573440 262144
+vim-9.1.0707_1 configure x86_64 https://repo-default.voidlinux.org/current 3801088 1834496
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionInstall, Name: "libsodium", Version: "1.0.20-1", Size: 180_456},
+					{Action: ActionInstall, Name: "vim", Version: "9.1.0707-1", Size: 1_834_496},
+					{Action: ActionUpgrade, Name: "curl", Version: "8.10.1-1", Size: 262_144},
+				},
+				DownloadSize: 2_277_096,
+			},
+		},
+		{
+			name:   "remove",
+			output: "vim-9.1.0707_1 remove x86_64 https://repo-default.voidlinux.org/current 3801088\n",
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionRemove, Name: "vim", Version: "9.1.0707-1", Size: 3_801_088},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseXBPSDryRun(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseXBPSDryRun() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package pkgmgr
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+)
+
+type Zypper struct{}
+
+// zypperNoMatches is zypper's exit status when a search or info finds
+// nothing; it still prints "No matching items found."
+const zypperNoMatches = 104
+
+func NewZypper() *Zypper {
+	return &Zypper{}
+}
+
+func init() {
+	Register(Backend{
+		Name:         "zypper",
+		DisplayName:  "Zypper",
+		Icon:         "📦",
+		Native:       true,
+		Priority:     40,
+		Capabilities: CapSearch | CapOrphans,
+		Detect:       func() bool { return commandExists("zypper") },
+		New:          func(cfg *config.Config) PackageManager { return NewZypper() },
+	})
+}
+
+// Name returns the backend name used in the config and registry
+func (z *Zypper) Name() string {
+	return "zypper"
+}
+
+func (z *Zypper) Install(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Zypper command: sudo zypper --non-interactive install <packages>
+	args := append([]string{"--non-interactive", "install"}, packages...)
+	cmd := privilegedCommand(ctx, "zypper", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// InstallVersions installs "name=version" targets; --oldpackage lets
+// Zypper go back to a version older than the installed one
+func (z *Zypper) InstallVersions(ctx context.Context, versions map[string]string) error {
+	args := append([]string{"--non-interactive", "install", "--oldpackage"}, versionTargets(versions, "=")...)
+	cmd := privilegedCommand(ctx, "zypper", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (z *Zypper) Remove(ctx context.Context, packages ...string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages specified")
+	}
+
+	// Zypper command: sudo zypper --non-interactive remove <packages>
+	args := append([]string{"--non-interactive", "remove"}, packages...)
+	cmd := privilegedCommand(ctx, "zypper", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func (z *Zypper) Update(ctx context.Context) error {
+	// Tumbleweed is a rolling release and must be upgraded with
+	// "zypper dup"; Leap uses a regular "zypper up"
+	upgrade := "up"
+	if isRollingSUSE(detectDistribution()) {
+		upgrade = "dup"
+	}
+
+	cmd := privilegedCommand(ctx, "zypper", "--non-interactive", "refresh")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return err
+	}
+
+	upgradeCmd := privilegedCommand(ctx, "zypper", "--non-interactive", upgrade)
+	upgradeCmd.Stdout = os.Stdout
+	upgradeCmd.Stderr = os.Stderr
+	return upgradeCmd.Run()
+}
+
+func (z *Zypper) Clean(ctx context.Context) error {
+	// Clean package cache
+	fmt.Println("🧹 Cleaning package cache...")
+	cleanCmd := privilegedCommand(ctx, "zypper", "clean", "--all")
+	cleanCmd.Stdout = os.Stdout
+	cleanCmd.Stderr = os.Stderr
+	err := cleanCmd.Run()
+	if err != nil {
+		return err
+	}
+
+	// Remove orphaned packages
+	fmt.Println("🗑️  Removing orphaned packages...")
+
+	// zypper packages --unneeded output: "S | Repository | Name | Version | Arch"
+	checkCmd := newCommand(ctx, "zypper", "--quiet", "packages", "--unneeded")
+	output, err := checkCmd.Output()
+	if err != nil {
+		return err
+	}
+
+	unneeded := parseZypperPackagesTable(string(output))
+	if len(unneeded) == 0 {
+		fmt.Println("✨ No orphaned packages found")
+		return nil
+	}
+
+	args := append([]string{"--non-interactive", "remove", "--clean-deps"}, unneeded...)
+	removeCmd := privilegedCommand(ctx, "zypper", args...)
+	removeCmd.Stdout = os.Stdout
+	removeCmd.Stderr = os.Stderr
+	return removeCmd.Run()
+}
+
+// Simulate previews an operation with zypper's --dry-run, reading the
+// summary from its XML output. zypper wants root even for a dry run.
+func (z *Zypper) Simulate(ctx context.Context, op Operation, packages ...string) (*ChangeSet, error) {
+	var args []string
+	switch op {
+	case OpInstall:
+		args = append([]string{"install", "--dry-run"}, packages...)
+	case OpRemove:
+		args = append([]string{"remove", "--dry-run"}, packages...)
+	case OpUpdate:
+		upgrade := "up"
+		if isRollingSUSE(detectDistribution()) {
+			upgrade = "dup"
+		}
+		args = []string{upgrade, "--dry-run"}
+	case OpClean:
+		output, err := newCommand(ctx, "zypper", "--quiet", "packages", "--unneeded").Output()
+		if err != nil {
+			return nil, err
+		}
+		unneeded := parseZypperPackagesTable(string(output))
+		if len(unneeded) == 0 {
+			return &ChangeSet{}, nil
+		}
+		args = append([]string{"remove", "--dry-run", "--clean-deps"}, unneeded...)
+	}
+
+	cmd := privilegedDryRun(ctx, "zypper", append([]string{"--non-interactive", "--xmlout"}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseZypperSummary(output)
+}
+
+// zypperSolvable is a package in zypper's XML install summary
+type zypperSolvable struct {
+	Type       string `xml:"type,attr"`
+	Name       string `xml:"name,attr"`
+	Edition    string `xml:"edition,attr"`
+	OldEdition string `xml:"edition-old,attr"`
+}
+
+// zypperStream is the part of zypper's --xmlout output we read:
+//
+//	<install-summary download-size="1048576" ...>
+//	  <to-upgrade>
+//	    <solvable type="package" name="curl" edition="8.6.0-1.1" edition-old="8.5.0-1.1" .../>
+//	  </to-upgrade>
+//	</install-summary>
+type zypperStream struct {
+	Summary struct {
+		DownloadSize int64            `xml:"download-size,attr"`
+		Install      []zypperSolvable `xml:"to-install>solvable"`
+		Upgrade      []zypperSolvable `xml:"to-upgrade>solvable"`
+		Downgrade    []zypperSolvable `xml:"to-downgrade>solvable"`
+		Reinstall    []zypperSolvable `xml:"to-reinstall>solvable"`
+		Remove       []zypperSolvable `xml:"to-remove>solvable"`
+	} `xml:"install-summary"`
+}
+
+// parseZypperSummary turns zypper's XML install summary into a change set
+func parseZypperSummary(output []byte) (*ChangeSet, error) {
+	var stream zypperStream
+	if err := xml.Unmarshal(output, &stream); err != nil {
+		return nil, fmt.Errorf("could not parse zypper output: %v", err)
+	}
+
+	set := &ChangeSet{DownloadSize: stream.Summary.DownloadSize}
+	groups := []struct {
+		action    ChangeAction
+		solvables []zypperSolvable
+	}{
+		{ActionInstall, stream.Summary.Install},
+		{ActionUpgrade, stream.Summary.Upgrade},
+		{ActionDowngrade, stream.Summary.Downgrade},
+		{ActionReinstall, stream.Summary.Reinstall},
+		{ActionRemove, stream.Summary.Remove},
+	}
+
+	for _, group := range groups {
+		for _, solvable := range group.solvables {
+			// Patterns and products come along with their packages
+			if solvable.Type != "" && solvable.Type != "package" {
+				continue
+			}
+			set.Changes = append(set.Changes, Change{
+				Action:     group.action,
+				Name:       solvable.Name,
+				Version:    solvable.Edition,
+				OldVersion: solvable.OldEdition,
+			})
+		}
+	}
+
+	return set, nil
+}
+
+// List lists all installed packages
+func (z *Zypper) List(ctx context.Context) ([]Package, error) {
+	// rpm -qa is much faster than zypper search --installed-only
+	packages, err := queryRPMPackages(ctx, "zypper")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	return packages, nil
+}
+
+// parseZypperPackagesTable extracts package names from zypper's table output
+func parseZypperPackagesTable(output string) []string {
+	var names []string
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) < 3 {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[2])
+		if name == "" || name == "Name" {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// isRollingSUSE reports whether the distribution is openSUSE Tumbleweed
+func isRollingSUSE(distro string) bool {
+	distro = strings.ToLower(distro)
+	return strings.Contains(distro, "tumbleweed") || strings.Contains(distro, "slowroll")
+}
+
+// Search searches the repositories for term
+func (z *Zypper) Search(ctx context.Context, term string) ([]Package, error) {
+	// zypper search output: "S | Name | Summary | Type", exits 104 when nothing matches
+	cmd := newCommand(ctx, "zypper", "--quiet", "search", "--type", "package", term)
+	output, err := cmd.Output()
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode == zypperNoMatches {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseZypperSearch(string(output)), nil
+}
+
+// Index lists every package in the repositories, which zypper search does
+// when given no term
+func (z *Zypper) Index(ctx context.Context) ([]Package, error) {
+	output, err := newCommand(ctx, "zypper", "--quiet", "search", "--type", "package").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseZypperSearch(string(output)), nil
+}
+
+// parseZypperSearch parses the "S | Name | Summary | Type" table
+func parseZypperSearch(output string) []Package {
+	var results []Package
+	lines := strings.SplitSeq(strings.TrimSpace(output), "\n")
+
+	for line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) < 3 {
+			continue
+		}
+
+		name := strings.TrimSpace(parts[1])
+		if name == "" || name == "Name" {
+			continue
+		}
+
+		results = append(results, Package{
+			Name:      name,
+			Summary:   strings.TrimSpace(parts[2]),
+			Source:    "zypper",
+			Installed: strings.HasPrefix(strings.TrimSpace(parts[0]), "i"),
+		})
+	}
+
+	return results
+}
+
+// Info returns details for a package
+func (z *Zypper) Info(ctx context.Context, name string) (*Package, error) {
+	cmd := newCommand(ctx, "zypper", "--quiet", "info", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", name, err)
+	}
+
+	fields := parseKeyValue(string(output))
+	if fields["Name"] == "" {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, name)
+	}
+
+	version, release := splitVersionRelease(fields["Version"])
+
+	return &Package{
+		Name:          fields["Name"],
+		Summary:       fields["Summary"],
+		Version:       version,
+		Release:       release,
+		Arch:          fields["Arch"],
+		Repository:    fields["Repository"],
+		Source:        "zypper",
+		InstalledSize: parseHumanSize(fields["Installed Size"]),
+		Installed:     strings.HasPrefix(fields["Installed"], "Yes"),
+	}, nil
+}
+
+// IsAvailable checks the repositories for an exact package name
+func (z *Zypper) IsAvailable(ctx context.Context, name string) bool {
+	// zypper search --match-exact <package> (exits 104 when nothing matches)
+	cmd := newCommand(ctx, "zypper", "--quiet", "search", "--match-exact", name)
+	output, err := cmd.Output()
+	if err != nil || len(output) == 0 {
+		return false
+	}
+	return true
+}
+
+// IsInstalled checks the rpm database for an exact package name
+func (z *Zypper) IsInstalled(ctx context.Context, name string) bool {
+	return isRPMPackageInstalled(ctx, name)
+}
@@ -0,0 +1,186 @@
+package pkgmgr
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestZypperSearch(t *testing.T) {
+	useFixtures(t, "zypper")
+
+	tests := []struct {
+		term string
+		want []Package
+	}{
+		{
+			term: "vim",
+			want: []Package{
+				{Name: "vim", Summary: "Vi IMproved", Source: "zypper", Installed: true},
+				{Name: "vim-data-common", Summary: "Common Data for Vi IMproved", Source: "zypper", Installed: true},
+				{Name: "vim-plugin-fugitive", Summary: "A Git wrapper so awesome, it should be illegal", Source: "zypper"},
+			},
+		},
+		{
+			term: "doesnotexist",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			got, err := NewZypper().Search(context.Background(), tt.term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) =\n%+v\nwant\n%+v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZypperInfo(t *testing.T) {
+	useFixtures(t, "zypper")
+
+	pkg, err := NewZypper().Info(context.Background(), "vim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Package{
+		Name:          "vim",
+		Summary:       "Vi IMproved",
+		Version:       "9.1.0836",
+		Release:       "1.1",
+		Arch:          "x86_64",
+		Repository:    "Main Repository (OSS)",
+		Source:        "zypper",
+		InstalledSize: 3_879_731, // 3.7 MiB
+		Installed:     true,
+	}
+	if !reflect.DeepEqual(pkg, want) {
+		t.Errorf("Info() =\n%+v\nwant\n%+v", pkg, want)
+	}
+}
+
+func TestZypperClean(t *testing.T) {
+	fake := useFixtures(t, "zypper")
+
+	if err := NewZypper().Clean(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"sudo", "zypper", "clean", "--all"},
+		{"zypper", "--quiet", "packages", "--unneeded"},
+		{"sudo", "zypper", "--non-interactive", "remove", "--clean-deps", "libpython3_11", "python311-six"},
+	}
+	if got := callArgs(fake); !reflect.DeepEqual(got, want) {
+		t.Errorf("Clean() ran\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestZypperSimulate(t *testing.T) {
+	fake := useFixtures(t, "zypper")
+	holdLock(t, "zypper")
+
+	set, err := NewZypper().Simulate(context.Background(), OpInstall, "neovim")
+	if err != nil {
+		t.Fatalf("Simulate() = %v while zypper holds its lock, want the dry run to go ahead", err)
+	}
+
+	want := &ChangeSet{
+		Changes: []Change{
+			{Action: ActionInstall, Name: "libluajit-5_1-2", Version: "2.1.20240815-1.1"},
+			{Action: ActionInstall, Name: "neovim", Version: "0.10.1-1.1"},
+		},
+		DownloadSize: 5 << 20,
+	}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("Simulate() =\n%+v\nwant\n%+v", set, want)
+	}
+
+	wantCalls := [][]string{{"sudo", "zypper", "--non-interactive", "--xmlout", "install", "--dry-run", "neovim"}}
+	if calls := callArgs(fake); !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("Simulate() ran %q, want %q", calls, wantCalls)
+	}
+}
+
+func TestParseZypperSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    *ChangeSet
+		wantErr bool
+	}{
+		{
+			name: "install with dependencies",
+			output: `<?xml version='1.0'?>
+<stream>
+<message type="info">Loading repository data...</message>
+<message type="info">Reading installed packages...</message>
+<message type="info">Resolving package dependencies...</message>
+<install-summary download-size="2154496" space-usage-diff="8130560" packages-to-change="3">
+<to-install>
+<solvable type="package" name="vim" edition="9.1.0836-1.1" arch="x86_64" repository="Main Repository (OSS)"/>
+<solvable type="package" name="vim-data-common" edition="9.1.0836-1.1" arch="noarch" repository="Main Repository (OSS)"/>
+<solvable type="pattern" name="enhanced_base" edition="20241015-1.1" arch="x86_64" repository="Main Repository (OSS)"/>
+</to-install>
+<to-upgrade>
+<solvable type="package" name="libsodium26" edition="1.0.20-1.2" edition-old="1.0.19-1.5" arch="x86_64" repository="Main Repository (OSS)"/>
+</to-upgrade>
+</install-summary>
+<message type="info">Dry run: no changes made.</message>
+</stream>
+`,
+			want: &ChangeSet{
+				DownloadSize: 2_154_496,
+				Changes: []Change{
+					{Action: ActionInstall, Name: "vim", Version: "9.1.0836-1.1"},
+					{Action: ActionInstall, Name: "vim-data-common", Version: "9.1.0836-1.1"},
+					{Action: ActionUpgrade, Name: "libsodium26", Version: "1.0.20-1.2", OldVersion: "1.0.19-1.5"},
+				},
+			},
+		},
+		{
+			name: "remove",
+			output: `<?xml version='1.0'?>
+<stream>
+<install-summary download-size="0" space-usage-diff="-3891200" packages-to-change="1">
+<to-remove>
+<solvable type="package" name="vim" edition="9.1.0836-1.1" arch="x86_64" repository="@System"/>
+</to-remove>
+</install-summary>
+</stream>
+`,
+			want: &ChangeSet{
+				Changes: []Change{
+					{Action: ActionRemove, Name: "vim", Version: "9.1.0836-1.1"},
+				},
+			},
+		},
+		{
+			name:   "nothing to do",
+			output: "<?xml version='1.0'?>\n<stream>\n<message type=\"info\">Nothing to do.</message>\n</stream>\n",
+			want:   &ChangeSet{},
+		},
+		{
+			name:    "not xml",
+			output:  "Repository 'Main Repository (OSS)' is out-of-date.",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseZypperSummary([]byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseZypperSummary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseZypperSummary() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,25 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+	"github.com/VaibhavPrakash0503/lazylinux/internal/hooks"
+	"github.com/VaibhavPrakash0503/lazylinux/internal/instance"
+	"github.com/VaibhavPrakash0503/lazylinux/internal/journal"
 	"github.com/VaibhavPrakash0503/lazylinux/internal/pkgmgr"
 	"github.com/VaibhavPrakash0503/lazylinux/internal/webapp"
 )
@@ -16,21 +30,61 @@ func main() {
 		return
 	}
 
+	// Global flags may appear anywhere; commands only see the rest
+	os.Args = parseGlobalFlags(os.Args)
+	if len(os.Args) < 2 {
+		showHelp()
+		return
+	}
+
 	command := os.Args[1]
 
+	// Ctrl-C and SIGTERM cancel the running operation instead of killing
+	// lazylinux outright, so it can say what got done
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if globals.dryRun {
+		switch command {
+		case "install", "remove", "update", "clean":
+			fmt.Println("🧪 Dry run: nothing will be changed")
+		default:
+			fmt.Fprintln(os.Stderr, "❌ --dry-run only works with install, remove, update and clean")
+			os.Exit(1)
+		}
+	}
+
+	if mode, ok := instanceMode(command, os.Args[2:]); ok {
+		lockInstance(ctx, mode)
+	}
+
 	switch command {
 	case "init":
-		handleInit()
+		handleInit(ctx)
 	case "install":
-		handleInstall()
+		handleInstall(ctx)
 	case "remove":
-		handleRemove()
+		handleRemove(ctx)
 	case "update":
-		handleUpdate()
+		handleUpdate(ctx)
+	case "refresh":
+		handleRefresh(ctx)
 	case "clean":
-		handleClean()
+		handleClean(ctx)
 	case "list":
-		handleList()
+		handleList(ctx, os.Args[2:])
+	case "info":
+		handleInfo(ctx)
+	case "search":
+		handleSearch(ctx, os.Args[2:])
+	case "alias":
+		handleAlias(os.Args[2:])
+	case "history":
+		handleHistory(os.Args[2:])
+	case "undo":
+		handleUndo(ctx)
+	case "backends":
+		handleBackends()
 	case "webapp":
 		if len(os.Args) < 3 {
 			showWebAppHelp()
@@ -44,6 +98,88 @@ func main() {
 	}
 }
 
+// globalFlags are options every command accepts
+type globalFlags struct {
+	noWait bool // Fail instead of waiting for a busy package manager or lazylinux run
+	dryRun bool // Show what would change without changing anything
+}
+
+var globals globalFlags
+
+// parseGlobalFlags records global flags in globals and returns args
+// without them
+func parseGlobalFlags(args []string) []string {
+	rest := []string{}
+	for _, arg := range args {
+		switch arg {
+		case "--no-wait":
+			globals.noWait = true
+		case "--dry-run":
+			globals.dryRun = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// instanceMode picks the instance lock a command needs. Commands that
+// touch neither packages nor config files don't take one.
+func instanceMode(command string, args []string) (instance.Mode, bool) {
+	switch command {
+	case "install", "remove", "update", "clean":
+		if globals.dryRun {
+			return instance.Shared, true
+		}
+		return instance.Exclusive, true
+	case "init", "undo", "refresh":
+		return instance.Exclusive, true
+	case "list", "info", "search", "history":
+		return instance.Shared, true
+	case "alias":
+		// Listing only reads config.yaml
+		if len(args) > 0 && args[0] == "list" {
+			return instance.Shared, true
+		}
+		return instance.Exclusive, true
+	case "webapp":
+		// Listing and opening only read webapps.yaml
+		if len(args) > 0 && (args[0] == "-l" || args[0] == "-o") {
+			return instance.Shared, true
+		}
+		return instance.Exclusive, true
+	}
+	return 0, false
+}
+
+// instanceLock is held until lazylinux exits. Keeping it reachable stops
+// the garbage collector from closing its file, which would drop the lock
+// in the middle of a transaction.
+var instanceLock *instance.Lock
+
+// lockInstance waits for other lazylinux runs that conflict with this
+// one, exiting if they don't finish in time. The lock is dropped on exit.
+func lockInstance(ctx context.Context, mode instance.Mode) {
+	wait := config.DefaultTimeouts.Lock
+	if cfg, err := config.LoadConfig(); err == nil {
+		wait = cfg.Timeouts.WithDefaults().Lock
+	}
+	if globals.noWait {
+		wait = 0
+	}
+
+	lock, err := instance.Acquire(ctx, mode, strings.Join(os.Args[1:], " "), wait)
+	if err != nil {
+		if ctx.Err() != nil {
+			os.Exit(exitAborted)
+		}
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		printAdvice(err)
+		os.Exit(exitCodeFor(err))
+	}
+	instanceLock = lock
+}
+
 // Helper function to check if initialized
 func mustBeInitialized() {
 	if !config.ConfigExists() {
@@ -65,44 +201,1097 @@ func loadConfigAndPM() (*config.Config, pkgmgr.PackageManager, error) {
 		return nil, nil, err
 	}
 
+	if err := hooks.Validate(cfg.Hooks); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+
 	return cfg, pm, nil
 }
 
-func handleInit() {
-	err := pkgmgr.RunInit()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Initialization failed: %v\n", err)
-		os.Exit(1)
+func handleInit(ctx context.Context) {
+	discoverPlugins()
+	err := pkgmgr.RunInit(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Initialization failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func handleInstall(ctx context.Context) {
+	mustBeInitialized()
+
+	if len(os.Args) < 3 {
+		fmt.Println("Error: No package specified")
+		fmt.Println("Usage: lazylinux install <package>...")
+		os.Exit(1)
+	}
+
+	cfg, pm, err := loadConfigAndPM()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	requests, err := expandAliases(cfg, os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	packages, lookup := []string{}, []string{}
+	for _, request := range requests {
+		packages = append(packages, request.name)
+		if request.source == "" {
+			lookup = append(lookup, request.name)
+		}
+	}
+	timeouts := cfg.Timeouts.WithDefaults()
+
+	// Resolve everything up front, then settle ambiguous names one by one.
+	// Aliases with a fixed source skip the lookup.
+	var resolved [][]pkgmgr.PackageSource
+	if len(lookup) > 0 {
+		fmt.Printf("\n🔍 Looking for %s...\n", strings.Join(lookup, ", "))
+		resolveCtx, cancel := context.WithTimeout(ctx, timeouts.Search)
+		resolved = pkgmgr.ResolvePackages(resolveCtx, lookup, pm, pkgmgr.EnabledSources(cfg))
+		cancel()
+	}
+
+	results := make([]installResult, len(requests))
+	batches := []*installBatch{}
+	for i, request := range requests {
+		pkg := request.name
+		results[i].pkg = pkg
+
+		var chosen *pkgmgr.PackageSource
+		var available []pkgmgr.PackageSource
+		if request.source != "" {
+			available = []pkgmgr.PackageSource{{Manager: request.source, PackageName: pkg, Available: true}}
+		} else {
+			available = pkgmgr.AvailableSources(resolved[0])
+			resolved = resolved[1:]
+		}
+		switch len(available) {
+		case 0:
+			if ctx.Err() == nil {
+				chosen = suggestAlternative(ctx, pkg, pm, cfg)
+			}
+			if chosen == nil && ctx.Err() == nil {
+				results[i].err = fmt.Errorf("%w: %s", pkgmgr.ErrPackageNotFound, pkg)
+				continue
+			}
+		case 1:
+			chosen = &available[0]
+		default:
+			chosen = pkgmgr.PromptUserChoice(ctx, available, pkg)
+		}
+		if ctx.Err() != nil {
+			reportInterrupted("Installed", nil, packages)
+		}
+
+		results[i].source = chosen.Manager
+		batch := batchFor(&batches, chosen.Manager)
+		batch.names = append(batch.names, chosen.PackageName)
+		batch.results = append(batch.results, &results[i])
+	}
+
+	printInstallPlan(batches, results)
+	startHooks("install", packages)
+
+	// One transaction per source
+	for _, batch := range batches {
+		name := pkgmgr.SourceDisplayName(batch.source)
+		manager, err := sourceManager(batch.source, pm, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\n❌ Failed to install from %s: %v\n", name, err)
+			for _, result := range batch.results {
+				result.done, result.err = true, err
+			}
+			continue
+		}
+
+		var changes []journal.Change
+		installCtx, cancel := context.WithTimeout(ctx, timeouts.Install)
+		if globals.dryRun {
+			fmt.Printf("\n📋 Installing from %s would change:\n", name)
+			err = previewChanges(installCtx, manager, pkgmgr.OpInstall, timeouts.Install, batch.names...)
+		} else {
+			fmt.Printf("\n📦 Installing %s from %s...\n", strings.Join(batch.names, ", "), name)
+			entry := journal.Entry{Action: "install", Requested: batch.names}
+			err = runTransaction(installCtx, cfg, manager, &entry, func() error {
+				return manager.Install(installCtx, batch.names...)
+			})
+			changes = entry.Changes
+		}
+		cancel()
+
+		if ctx.Err() != nil {
+			reportInterrupted("Installed", installedNames(results), unfinishedNames(results))
+		}
+		if err != nil && !globals.dryRun {
+			fmt.Fprintf(os.Stderr, "❌ Failed to install from %s: %v\n", name, timeoutError(err, timeouts.Install))
+			printAdvice(err)
+		}
+
+		for j, result := range batch.results {
+			result.done = true
+			result.err = err
+
+			// Sources that install one package at a time may have got
+			// part of the way; the listings taken around the transaction
+			// show which packages it did install
+			if err != nil && !globals.dryRun && len(batch.names) > 1 && changedTo(changes, batch.names[j]) {
+				result.err = nil
+			}
+		}
+	}
+
+	finishHooks()
+
+	exitCode := 0
+	for _, result := range results {
+		if result.err != nil {
+			exitCode = max(exitCode, exitCodeFor(result.err))
+		}
+	}
+
+	if globals.dryRun {
+		fmt.Println()
+		fmt.Println("✅ Dry run complete, nothing was changed")
+	} else {
+		printInstallSummary(results)
+	}
+	os.Exit(exitCode)
+}
+
+// installResult tracks one requested package through a batch install
+type installResult struct {
+	pkg    string // Name as given on the command line
+	source string // Chosen source, empty when not found
+	done   bool   // Its transaction has run
+	err    error
+}
+
+// installBatch is everything to install from one source in one go
+type installBatch struct {
+	source  string
+	names   []string // Package names as the source knows them
+	results []*installResult
+}
+
+// batchFor returns the batch for source, adding it if needed, so batches
+// run in the order sources were first chosen
+func batchFor(batches *[]*installBatch, source string) *installBatch {
+	for _, batch := range *batches {
+		if batch.source == source {
+			return batch
+		}
+	}
+	batch := &installBatch{source: source}
+	*batches = append(*batches, batch)
+	return batch
+}
+
+// printInstallPlan shows what will be installed from where
+func printInstallPlan(batches []*installBatch, results []installResult) {
+	fmt.Println()
+	fmt.Println("📋 Install plan:")
+
+	for _, batch := range batches {
+		icon := "📦"
+		if backend, ok := pkgmgr.LookupBackend(batch.source); ok {
+			icon = backend.Icon
+		}
+
+		names := []string{}
+		for j, name := range batch.names {
+			if pkg := batch.results[j].pkg; pkg != name {
+				name = fmt.Sprintf("%s (%s)", name, pkg)
+			}
+			names = append(names, name)
+		}
+		fmt.Printf("  %s %s: %s\n", icon, pkgmgr.SourceDisplayName(batch.source), strings.Join(names, ", "))
+	}
+
+	missing := []string{}
+	for _, result := range results {
+		if result.source == "" {
+			missing = append(missing, result.pkg)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("  ❌ Not found: %s\n", strings.Join(missing, ", "))
+	}
+}
+
+// printInstallSummary reports how each requested package fared
+func printInstallSummary(results []installResult) {
+	fmt.Println()
+	fmt.Println("📊 Summary:")
+
+	for _, result := range results {
+		switch {
+		case result.err == nil:
+			fmt.Printf("  ✅ %s (%s)\n", result.pkg, pkgmgr.SourceDisplayName(result.source))
+		case result.source == "":
+			fmt.Printf("  ❌ %s: not found in any source\n", result.pkg)
+		default:
+			fmt.Printf("  ❌ %s (%s): %v\n", result.pkg, pkgmgr.SourceDisplayName(result.source), result.err)
+		}
+	}
+}
+
+// installedNames lists the packages whose transaction succeeded
+func installedNames(results []installResult) []string {
+	names := []string{}
+	for _, result := range results {
+		if result.done && result.err == nil {
+			names = append(names, result.pkg)
+		}
+	}
+	return names
+}
+
+// unfinishedNames lists the packages that failed or never ran
+func unfinishedNames(results []installResult) []string {
+	names := []string{}
+	for _, result := range results {
+		if !result.done || result.err != nil {
+			names = append(names, result.pkg)
+		}
+	}
+	return names
+}
+
+// suggestAlternative offers packages with names close to one nothing
+// matched, returning the one picked or nil
+func suggestAlternative(ctx context.Context, pkg string, pm pkgmgr.PackageManager, cfg *config.Config) *pkgmgr.PackageSource {
+	suggestCtx, cancel := context.WithTimeout(ctx, cfg.Timeouts.WithDefaults().Search)
+	suggestions := pkgmgr.SuggestPackages(suggestCtx, pkg, pm, pkgmgr.EnabledSources(cfg))
+	cancel()
+
+	return pkgmgr.PromptSuggestion(ctx, pkg, suggestions)
+}
+
+// packageRequest is a package named on the command line, after aliases
+// are expanded
+type packageRequest struct {
+	name   string
+	source string // Fixed by an alias; empty to look the package up
+}
+
+// expandAliases replaces alias names with the packages they stand for
+func expandAliases(cfg *config.Config, args []string) ([]packageRequest, error) {
+	requests := []packageRequest{}
+	for _, arg := range args {
+		alias, ok := cfg.Aliases[arg]
+		if !ok {
+			requests = append(requests, packageRequest{name: arg})
+			continue
+		}
+		if _, known := pkgmgr.LookupBackend(alias.Source); alias.Source != "" && !known {
+			return nil, fmt.Errorf("alias %s uses unknown source %s (see lazylinux backends)", arg, alias.Source)
+		}
+
+		fmt.Printf("📎 %s → %s\n", arg, describeAlias(alias))
+		for _, pkg := range alias.Packages {
+			requests = append(requests, packageRequest{name: pkg, source: alias.Source})
+		}
+	}
+	return requests, nil
+}
+
+// describeAlias formats an alias as "kubectl, helm, k9s (Flatpak)"
+func describeAlias(alias config.Alias) string {
+	description := strings.Join(alias.Packages, ", ")
+	if alias.Source != "" {
+		description += fmt.Sprintf(" (%s)", pkgmgr.SourceDisplayName(alias.Source))
+	}
+	return description
+}
+
+func handleRemove(ctx context.Context) {
+	mustBeInitialized()
+
+	if len(os.Args) < 3 {
+		fmt.Println("Error: No package specified")
+		fmt.Println("Usage: lazylinux remove <package>...")
+		os.Exit(1)
+	}
+
+	cfg, pm, err := loadConfigAndPM()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	requests, err := expandAliases(cfg, os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	names := []string{}
+	for _, request := range requests {
+		names = append(names, request.name)
+	}
+	startHooks("remove", names)
+
+	removed, failed := []string{}, []string{}
+	exitCode := 0
+	for i, request := range requests {
+		err := removePackage(ctx, request, pm, cfg)
+		if ctx.Err() != nil {
+			unfinished := failed
+			for _, rest := range requests[i:] {
+				unfinished = append(unfinished, rest.name)
+			}
+			reportInterrupted("Removed", removed, unfinished)
+		}
+		if err != nil {
+			failed = append(failed, request.name)
+			exitCode = max(exitCode, exitCodeFor(err))
+		} else {
+			removed = append(removed, request.name)
+		}
+	}
+
+	finishHooks()
+	os.Exit(exitCode)
+}
+
+func removePackage(ctx context.Context, request packageRequest, pm pkgmgr.PackageManager, cfg *config.Config) error {
+	pkg := request.name
+	timeouts := cfg.Timeouts.WithDefaults()
+
+	chosen := &pkgmgr.PackageSource{Manager: request.source, PackageName: pkg, Available: true}
+	if request.source == "" {
+		fmt.Printf("\n🔍 Looking for '%s' to remove...\n", pkg)
+		resolveCtx, cancel := context.WithTimeout(ctx, timeouts.Search)
+		sources := pkgmgr.ResolvePackageForRemove(resolveCtx, pkg, pm, pkgmgr.EnabledSources(cfg))
+		cancel()
+		chosen = pkgmgr.PromptUserChoice(ctx, sources, pkg)
+	}
+
+	if chosen == nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fmt.Printf("❌ Package '%s' not found in any source\n", pkg)
+		return fmt.Errorf("%w: %s", pkgmgr.ErrPackageNotFound, pkg)
+	}
+
+	manager, err := sourceManager(chosen.Manager, pm, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to remove '%s': %v\n", pkg, err)
+		return err
+	}
+
+	removeCtx, cancel := context.WithTimeout(ctx, timeouts.Install)
+	defer cancel()
+
+	if globals.dryRun {
+		fmt.Printf("\n📋 Removing '%s' from %s would change:\n", pkg, chosen.Manager)
+		return previewChanges(removeCtx, manager, pkgmgr.OpRemove, timeouts.Install, chosen.PackageName)
+	}
+
+	fmt.Printf("\n📦 Removing '%s' from %s...\n", pkg, chosen.Manager)
+	entry := journal.Entry{Action: "remove", Requested: []string{chosen.PackageName}}
+	removeErr := runTransaction(removeCtx, cfg, manager, &entry, func() error {
+		return manager.Remove(removeCtx, chosen.PackageName)
+	})
+
+	if removeErr != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to remove '%s': %v\n", pkg, timeoutError(removeErr, timeouts.Install))
+			printAdvice(removeErr)
+		}
+		return removeErr
+	}
+
+	fmt.Printf("✅ Successfully removed '%s'\n", pkg)
+	return nil
+}
+
+// previewChanges prints what op would change in pm, for --dry-run.
+// Backends that can't tell are skipped with a warning.
+func previewChanges(ctx context.Context, pm pkgmgr.PackageManager, op pkgmgr.Operation, timeout time.Duration, packages ...string) error {
+	set, err := pkgmgr.Simulate(ctx, pm, op, packages...)
+	if errors.Is(err, pkgmgr.ErrSimulationUnsupported) {
+		fmt.Printf("⚠️  %v, skipping\n", err)
+		return nil
+	}
+	if err != nil {
+		if ctx.Err() == nil || errors.Is(err, context.DeadlineExceeded) {
+			fmt.Fprintf(os.Stderr, "❌ Could not preview changes: %v\n", timeoutError(err, timeout))
+			printAdvice(err)
+		}
+		return err
+	}
+
+	printChangeSet(set)
+	return nil
+}
+
+// printChangeSet lists a change set grouped by what happens to each package
+func printChangeSet(set *pkgmgr.ChangeSet) {
+	if len(set.Changes) == 0 {
+		fmt.Println("✨ Nothing to do")
+		return
+	}
+
+	groups := []struct {
+		action pkgmgr.ChangeAction
+		title  string
+	}{
+		{pkgmgr.ActionInstall, "➕ Install"},
+		{pkgmgr.ActionUpgrade, "⬆️  Upgrade"},
+		{pkgmgr.ActionDowngrade, "⬇️  Downgrade"},
+		{pkgmgr.ActionReinstall, "🔁 Reinstall"},
+		{pkgmgr.ActionRemove, "➖ Remove"},
+	}
+
+	for _, group := range groups {
+		changes := set.Filter(group.action)
+		if len(changes) == 0 {
+			continue
+		}
+
+		fmt.Printf("%s (%d):\n", group.title, len(changes))
+		for _, change := range changes {
+			line := "  • " + change.Name
+			if change.OldVersion != "" && change.Version != "" {
+				line += fmt.Sprintf(" %s → %s", change.OldVersion, change.Version)
+			} else if change.Version != "" {
+				line += " " + change.Version
+			}
+			if change.Size > 0 {
+				line += fmt.Sprintf(" [%s]", pkgmgr.FormatSize(change.Size))
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if set.DownloadSize > 0 {
+		fmt.Printf("📥 Download: %s\n", pkgmgr.FormatSize(set.DownloadSize))
+	}
+}
+
+// runTransaction runs a transaction on manager, running the command's
+// pre-hooks first if it's the command's first. All but cleans are added
+// to the journal with the versions they changed; the listings run even
+// after Ctrl-C, so an interrupted transaction is recorded too, and entry
+// is left holding the changes. A journal that can't be written only warns,
+// since the transaction already ran.
+func runTransaction(ctx context.Context, cfg *config.Config, manager pkgmgr.PackageManager, entry *journal.Entry, run func() error) error {
+	entry.Command = strings.Join(os.Args[1:], " ")
+	entry.Source = manager.Name()
+
+	if err := commandHooks.before(ctx, cfg); err != nil {
+		return err
+	}
+
+	journaled := entry.Action != "clean"
+	listTimeout := cfg.Timeouts.WithDefaults().Search
+
+	var before map[string]string
+	if journaled {
+		before = installedVersions(ctx, manager, listTimeout)
+	}
+	err := run()
+
+	entry.Success = err == nil
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if journaled {
+		if after := installedVersions(ctx, manager, listTimeout); before != nil && after != nil {
+			entry.Changes = journal.Diff(before, after)
+			entry.Recorded = true
+		}
+		if journalErr := journal.Append(entry); journalErr != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", journalErr)
+		}
+	}
+
+	commandHooks.record(*entry)
+	return err
+}
+
+// transactionHooks runs a command's hooks once, however many sources its
+// transactions span: the pre-hooks before the first transaction, and the
+// post-hooks with how each one went when the command is done
+type transactionHooks struct {
+	cfg     *config.Config
+	event   hooks.Event
+	started bool
+	err     error // A failing pre-hook, which stops every transaction
+}
+
+// commandHooks are the running command's hooks
+var commandHooks transactionHooks
+
+// startHooks sets up the hooks for a command doing operation to packages.
+// Nothing runs until its first transaction.
+func startHooks(operation string, packages []string) {
+	commandHooks = transactionHooks{event: hooks.Event{
+		Operation: operation,
+		Packages:  packages,
+		Command:   strings.Join(os.Args[1:], " "),
+	}}
+}
+
+// before runs the pre-hooks ahead of the command's first transaction
+func (h *transactionHooks) before(ctx context.Context, cfg *config.Config) error {
+	if !h.started {
+		h.started, h.cfg = true, cfg
+		h.err = hooks.Run(ctx, cfg.Hooks, cfg.Timeouts.WithDefaults().Hook, hooks.Pre, h.event)
+	}
+	return h.err
+}
+
+// record adds a finished transaction to what the post-hooks are told
+func (h *transactionHooks) record(entry journal.Entry) {
+	transaction := hooks.Transaction{
+		Source:   entry.Source,
+		Packages: entry.Requested,
+		Result:   "success",
+		Error:    entry.Error,
+		Changes:  entry.Changes,
+	}
+	if !entry.Success {
+		transaction.Result = "failure"
+	}
+	h.event.Transactions = append(h.event.Transactions, transaction)
+}
+
+// finishHooks runs the post-hooks once the command's transactions are
+// done, even after Ctrl-C. Nothing runs if no transaction did. A failing
+// post-hook only warns, since the transactions already ran.
+func finishHooks() {
+	h := &commandHooks
+	if len(h.event.Transactions) == 0 {
+		return
+	}
+
+	h.event.Result = "success"
+	for _, transaction := range h.event.Transactions {
+		if transaction.Result != "success" {
+			h.event.Result = "failure"
+		}
+	}
+
+	err := hooks.Run(context.Background(), h.cfg.Hooks, h.cfg.Timeouts.WithDefaults().Hook, hooks.Post, h.event)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+	}
+	h.event.Transactions = nil
+}
+
+// changedTo reports whether changes show name installed or moved to
+// another version. Nothing counts when no listing was taken.
+func changedTo(changes []journal.Change, name string) bool {
+	for _, change := range changes {
+		if change.Name == name && change.After != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// installedVersions lists what manager has installed, or nil if it can't
+func installedVersions(ctx context.Context, manager pkgmgr.PackageManager, timeout time.Duration) map[string]string {
+	listCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	versions, err := pkgmgr.InstalledVersions(listCtx, manager)
+	if err != nil {
+		return nil
+	}
+	return versions
+}
+
+// timeoutError replaces a bare "context deadline exceeded" with the
+// timeout that was hit
+func timeoutError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s (see timeouts in %s)", timeout, config.GetConfigPath())
+	}
+	return err
+}
+
+// Exit codes for failures lazylinux recognises; anything else exits 1
+const (
+	exitNotFound   = 10
+	exitPermission = 11
+	exitLocked     = 12
+	exitNetwork    = 13
+	exitConflict   = 14
+	exitDiskFull   = 15
+	exitAborted    = 130
+)
+
+// exitCodeFor picks the exit code for a failed operation
+func exitCodeFor(err error) int {
+	switch {
+	case errors.Is(err, pkgmgr.ErrPackageNotFound):
+		return exitNotFound
+	case errors.Is(err, pkgmgr.ErrPermissionDenied):
+		return exitPermission
+	case errors.Is(err, pkgmgr.ErrDatabaseLocked), errors.Is(err, instance.ErrBusy):
+		return exitLocked
+	case errors.Is(err, pkgmgr.ErrNetworkUnavailable):
+		return exitNetwork
+	case errors.Is(err, pkgmgr.ErrDependencyConflict):
+		return exitConflict
+	case errors.Is(err, pkgmgr.ErrDiskFull):
+		return exitDiskFull
+	case errors.Is(err, pkgmgr.ErrAborted):
+		return exitAborted
+	}
+	return 1
+}
+
+// printAdvice suggests a fix for failures lazylinux recognises
+func printAdvice(err error) {
+	advice := ""
+	switch {
+	case errors.Is(err, pkgmgr.ErrPackageNotFound):
+		advice = "Check the package name, or enable more sources in " + config.GetConfigPath()
+	case errors.Is(err, pkgmgr.ErrPermissionDenied):
+		tool := pkgmgr.EscalationTool()
+		if tool == "" {
+			tool = "root"
+		}
+		advice = fmt.Sprintf("Make sure your user may run package managers through %s, or set escalation in %s", tool, config.GetConfigPath())
+	case errors.Is(err, pkgmgr.ErrDatabaseLocked):
+		advice = "Another package manager is running. Wait for it to finish, then try again"
+	case errors.Is(err, instance.ErrBusy):
+		advice = "Wait for the other lazylinux command to finish, then try again"
+	case errors.Is(err, pkgmgr.ErrNetworkUnavailable):
+		advice = "Check your internet connection and mirrors, then try again"
+	case errors.Is(err, pkgmgr.ErrDependencyConflict):
+		advice = "Run 'lazylinux update' first; if it still fails, resolve the conflict with your package manager"
+	case errors.Is(err, pkgmgr.ErrDiskFull):
+		advice = "Free up some space, for example with 'lazylinux clean'"
+	case errors.Is(err, hooks.ErrHookFailed):
+		advice = fmt.Sprintf("Fix the hook, or remove it from %s or %s", config.GetConfigPath(), hooks.Dir())
+	}
+
+	if advice != "" {
+		fmt.Fprintf(os.Stderr, "💡 %s\n", advice)
+	}
+}
+
+// reportInterrupted lists what finished before Ctrl-C or SIGTERM, runs the
+// post-hooks for the transactions that ran, and exits with the
+// conventional status for an interrupted command
+func reportInterrupted(action string, finished, unfinished []string) {
+	fmt.Println()
+	fmt.Println("⏹️  Interrupted")
+	if len(finished) > 0 {
+		fmt.Printf("  ✅ %s: %s\n", action, strings.Join(finished, ", "))
+	}
+	if len(unfinished) > 0 {
+		fmt.Printf("  ❌ Not %s: %s\n", strings.ToLower(action), strings.Join(unfinished, ", "))
+	}
+	finishHooks()
+	os.Exit(exitAborted)
+}
+
+// sourceManager returns the package manager behind a resolved source
+func sourceManager(source string, pm pkgmgr.PackageManager, cfg *config.Config) (pkgmgr.PackageManager, error) {
+	if source == pm.Name() {
+		return pm, nil
+	}
+	return pkgmgr.NewBackend(source, cfg)
+}
+
+func handleInfo(ctx context.Context) {
+	mustBeInitialized()
+
+	if len(os.Args) < 3 {
+		fmt.Println("Error: No package specified")
+		fmt.Println("Usage: lazylinux info <package>")
+		os.Exit(1)
+	}
+
+	cfg, pm, err := loadConfigAndPM()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	pkg := os.Args[2]
+	timeout := cfg.Timeouts.WithDefaults().Search
+	fmt.Printf("\n🔍 Looking for '%s'...\n", pkg)
+
+	resolveCtx, cancel := context.WithTimeout(ctx, timeout)
+	sources := pkgmgr.ResolvePackage(resolveCtx, pkg, pm, pkgmgr.EnabledSources(cfg))
+	cancel()
+	chosen := pkgmgr.PromptUserChoice(ctx, sources, pkg)
+	if chosen == nil && ctx.Err() == nil {
+		chosen = suggestAlternative(ctx, pkg, pm, cfg)
+	}
+
+	if chosen == nil {
+		if ctx.Err() != nil {
+			os.Exit(exitAborted)
+		}
+		fmt.Printf("❌ Package '%s' not found in any source\n", pkg)
+		os.Exit(exitCodeFor(pkgmgr.ErrPackageNotFound))
+	}
+
+	infoCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	manager, err := sourceManager(chosen.Manager, pm, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	info, err := manager.Info(infoCtx, chosen.PackageName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", timeoutError(err, timeout))
+		printAdvice(err)
+		os.Exit(exitCodeFor(err))
+	}
+
+	fmt.Println()
+	fmt.Printf("📦 %s\n", info.Name)
+	if info.DisplayName != "" && info.DisplayName != info.Name {
+		fmt.Printf("  Name:       %s\n", info.DisplayName)
+	}
+	if info.Summary != "" {
+		fmt.Printf("  Summary:    %s\n", info.Summary)
+	}
+	if v := info.FullVersion(); v != "" {
+		fmt.Printf("  Version:    %s\n", v)
+	}
+	if info.Arch != "" {
+		fmt.Printf("  Arch:       %s\n", info.Arch)
+	}
+	fmt.Printf("  Source:     %s\n", chosen.Manager)
+	if info.Repository != "" {
+		fmt.Printf("  Repository: %s\n", info.Repository)
+	}
+	if info.InstalledSize > 0 {
+		fmt.Printf("  Size:       %s\n", pkgmgr.FormatSize(info.InstalledSize))
+	}
+	if info.URL != "" {
+		fmt.Printf("  URL:        %s\n", info.URL)
+	}
+	if info.Installed {
+		fmt.Println("  Installed:  yes")
+	} else {
+		fmt.Println("  Installed:  no")
+	}
+}
+
+func handleSearch(ctx context.Context, args []string) {
+	mustBeInitialized()
+
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+	source := searchCmd.String("source", "", "Only search this source, e.g. dnf or flatpak")
+	limit := searchCmd.Int("limit", 20, "Show at most this many results, 0 for all")
+	asJSON := searchCmd.Bool("json", false, "Print results as JSON")
+
+	terms, err := parseInterspersed(searchCmd, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if len(terms) == 0 {
+		fmt.Println("Error: No search term specified")
+		fmt.Println("Usage: lazylinux search [--source <name>] [--limit <n>] <term>")
+		os.Exit(1)
+	}
+	term := strings.Join(terms, " ")
+
+	cfg, pm, err := loadConfigAndPM()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	managers := append([]pkgmgr.PackageManager{pm}, pkgmgr.EnabledSources(cfg)...)
+	if *source != "" {
+		managers = slices.DeleteFunc(managers, func(manager pkgmgr.PackageManager) bool {
+			return manager.Name() != *source
+		})
+		if len(managers) == 0 {
+			fmt.Fprintf(os.Stderr, "❌ Source %s is not enabled (try one of: %s)\n", *source,
+				strings.Join(sourceIDs(pm, cfg), ", "))
+			os.Exit(1)
+		}
+	}
+
+	timeout := cfg.Timeouts.WithDefaults().Search
+	if !*asJSON {
+		fmt.Printf("🔍 Searching for '%s' in %s...\n", term, strings.Join(managerNames(managers), ", "))
+	}
+
+	// Naming a source asks for its full search, however slow
+	searchCtx, cancel := context.WithTimeout(ctx, timeout)
+	results, errs := pkgmgr.SearchAll(searchCtx, term, managers, *source != "")
+	cancel()
+	if ctx.Err() != nil {
+		os.Exit(exitAborted)
+	}
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", timeoutError(err, timeout))
+	}
+
+	total := len(results)
+	if *limit > 0 && total > *limit {
+		results = results[:*limit]
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Println()
+	if total == 0 {
+		fmt.Printf("❌ Nothing matches '%s'\n", term)
+		os.Exit(exitCodeFor(pkgmgr.ErrPackageNotFound))
+	}
+
+	for _, result := range results {
+		installed := ""
+		if result.Installed {
+			installed = " ✅"
+		}
+		fmt.Printf("  %-8s %-8s %s %s%s\n", "["+result.Label()+"]", pkgmgr.SourceDisplayName(result.Source),
+			result.Name, result.FullVersion(), installed)
+
+		if result.DisplayName != "" && result.DisplayName != result.Name {
+			fmt.Printf("                    %s\n", result.DisplayName)
+		}
+		if result.Summary != "" {
+			fmt.Printf("                    %s\n", result.Summary)
+		}
+	}
+	if total > len(results) {
+		fmt.Printf("\n  ... and %d more (use --limit 0 to see all)\n", total-len(results))
+	}
+}
+
+// sourceIDs lists the backend names search can be limited to
+func sourceIDs(pm pkgmgr.PackageManager, cfg *config.Config) []string {
+	ids := []string{pm.Name()}
+	for _, source := range pkgmgr.EnabledSources(cfg) {
+		ids = append(ids, source.Name())
+	}
+	return ids
+}
+
+// parseInterspersed parses flags that may come before or after the
+// positional arguments, which the flag package stops at, and returns those
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+func handleUpdate(ctx context.Context) {
+	mustBeInitialized()
+
+	cfg, pm, err := loadConfigAndPM()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("🔄 Updating packages...")
+
+	exitCode := 0
+	timeout := cfg.Timeouts.WithDefaults().Update
+
+	// Update native package manager first, then every enabled source
+	managers := append([]pkgmgr.PackageManager{pm}, pkgmgr.EnabledSources(cfg)...)
+	updated, failed := []string{}, []string{}
+	startHooks("update", nil)
+	for i, manager := range managers {
+		name := pkgmgr.DisplayName(manager)
+
+		fmt.Println()
+		updateCtx, cancel := context.WithTimeout(ctx, timeout)
+		if globals.dryRun {
+			fmt.Printf("📋 Updating %s packages would change:\n", name)
+			err = previewChanges(updateCtx, manager, pkgmgr.OpUpdate, timeout)
+		} else {
+			fmt.Printf("🔄 Updating %s packages...\n", name)
+			err = runTransaction(updateCtx, cfg, manager, &journal.Entry{Action: "update"}, func() error {
+				return manager.Update(updateCtx)
+			})
+		}
+		cancel()
+		if ctx.Err() != nil {
+			reportInterrupted("Updated", updated, append(failed, managerNames(managers[i:])...))
+		}
+		if err != nil {
+			if !globals.dryRun {
+				fmt.Fprintf(os.Stderr, "❌ Failed to update %s packages: %v\n", name, timeoutError(err, timeout))
+				printAdvice(err)
+			}
+			failed = append(failed, name)
+			exitCode = max(exitCode, exitCodeFor(err))
+		} else if !globals.dryRun {
+			fmt.Printf("✅ %s packages updated\n", name)
+			updated = append(updated, name)
+			refreshIndex(ctx, manager, timeout)
+		}
+	}
+	finishHooks()
+
+	fmt.Println()
+	if exitCode == 0 && globals.dryRun {
+		fmt.Println("✅ Dry run complete, nothing was changed")
+	} else if exitCode == 0 {
+		fmt.Println("✅ All updates complete!")
+	} else {
+		fmt.Println("⚠️  Some updates failed. Check errors above.")
+		os.Exit(exitCode)
+	}
+}
+
+// refreshIndex rebuilds a source's package index after its metadata
+// changed, warning if that fails
+func refreshIndex(ctx context.Context, manager pkgmgr.PackageManager, timeout time.Duration) {
+	refreshCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := pkgmgr.RefreshIndex(refreshCtx, manager)
+	if err != nil && !errors.Is(err, pkgmgr.ErrIndexUnsupported) {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not refresh the package index: %v\n", timeoutError(err, timeout))
 	}
 }
 
-func handleInstall() {
+func handleRefresh(ctx context.Context) {
 	mustBeInitialized()
 
-	if len(os.Args) < 3 {
-		fmt.Println("Error: No package specified")
-		fmt.Println("Usage: lazylinux install <package>...")
+	cfg, pm, err := loadConfigAndPM()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	fmt.Println("🔄 Refreshing package indexes...")
+
+	exitCode := 0
+	timeout := cfg.Timeouts.WithDefaults().Update
+
+	managers := append([]pkgmgr.PackageManager{pm}, pkgmgr.EnabledSources(cfg)...)
+	refreshed := []string{}
+	for i, manager := range managers {
+		refreshCtx, cancel := context.WithTimeout(ctx, timeout)
+		count, err := pkgmgr.RefreshIndex(refreshCtx, manager)
+		cancel()
+		if ctx.Err() != nil {
+			reportInterrupted("Refreshed", refreshed, managerNames(managers[i:]))
+		}
+
+		switch {
+		case errors.Is(err, pkgmgr.ErrIndexUnsupported):
+			fmt.Printf("⚠️  %v, skipping\n", err)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "❌ %v\n", timeoutError(err, timeout))
+			exitCode = max(exitCode, exitCodeFor(err))
+		default:
+			fmt.Printf("✅ %s: %d packages\n", pkgmgr.DisplayName(manager), count)
+			refreshed = append(refreshed, pkgmgr.DisplayName(manager))
+		}
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+func handleClean(ctx context.Context) {
+	mustBeInitialized()
+
 	cfg, pm, err := loadConfigAndPM()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	packages := os.Args[2:]
-	for _, pkg := range packages {
-		installPackage(pkg, pm, cfg)
+	fmt.Println("🧼 Cleaning system...")
+
+	timeout := cfg.Timeouts.WithDefaults().Clean
+	exitCode := 0
+
+	// Clean native package manager first, then every enabled source
+	managers := append([]pkgmgr.PackageManager{pm}, pkgmgr.EnabledSources(cfg)...)
+	cleaned, failed := []string{}, []string{}
+	startHooks("clean", nil)
+	for i, manager := range managers {
+		name := pkgmgr.DisplayName(manager)
+
+		fmt.Println()
+		cleanCtx, cancel := context.WithTimeout(ctx, timeout)
+		if globals.dryRun {
+			fmt.Printf("📋 Cleaning %s would change:\n", name)
+			err = previewChanges(cleanCtx, manager, pkgmgr.OpClean, timeout)
+		} else {
+			fmt.Printf("🧹 Cleaning %s...\n", name)
+			err = runTransaction(cleanCtx, cfg, manager, &journal.Entry{Action: "clean"}, func() error {
+				return manager.Clean(cleanCtx)
+			})
+		}
+		cancel()
+		if ctx.Err() != nil {
+			reportInterrupted("Cleaned", cleaned, append(failed, managerNames(managers[i:])...))
+		}
+		if err != nil {
+			if !globals.dryRun {
+				fmt.Fprintf(os.Stderr, "❌ Failed to clean %s: %v\n", name, timeoutError(err, timeout))
+				printAdvice(err)
+			}
+			failed = append(failed, name)
+			exitCode = max(exitCode, exitCodeFor(err))
+		} else if !globals.dryRun {
+			fmt.Printf("✅ %s cleaned\n", name)
+			cleaned = append(cleaned, name)
+		}
+	}
+	finishHooks()
+
+	fmt.Println()
+	if exitCode != 0 {
+		fmt.Println("⚠️  Some sources could not be cleaned. Check errors above.")
+		os.Exit(exitCode)
+	}
+	if globals.dryRun {
+		fmt.Println("✅ Dry run complete, nothing was changed")
+		return
 	}
+	fmt.Println("✅ System cleaned!")
 }
 
-func handleRemove() {
+func handleList(ctx context.Context, args []string) {
 	mustBeInitialized()
 
-	if len(os.Args) < 3 {
-		fmt.Println("Error: No package specified")
-		fmt.Println("Usage: lazylinux remove <package>...")
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	asJSON := listCmd.Bool("json", false, "Print packages as JSON")
+	explicitOnly := listCmd.Bool("explicit", false, "Only show explicitly installed packages")
+
+	err := listCmd.Parse(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error parsing arguments: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -112,197 +1301,485 @@ func handleRemove() {
 		os.Exit(1)
 	}
 
-	packages := os.Args[2:]
-	for _, pkg := range packages {
-		removePackage(pkg, pm, cfg)
+	type section struct {
+		title    string
+		packages []pkgmgr.Package
 	}
-}
 
-func installPackage(pkg string, pm pkgmgr.PackageManager, cfg *config.Config) {
-	fmt.Printf("\n🔍 Looking for '%s'...\n", pkg)
+	timeout := cfg.Timeouts.WithDefaults().Search
 
-	sources := pkgmgr.ResolvePackage(pkg, pm, cfg.FlatpakEnabled)
-	chosen := pkgmgr.PromptUserChoice(sources, pkg)
+	sections := []section{}
+	addSection := func(title string, lister func(context.Context) ([]pkgmgr.Package, error)) {
+		listCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
 
-	if chosen == nil {
-		fmt.Printf("❌ Package '%s' not found in any source\n", pkg)
-		return
+		packages, err := lister(listCtx)
+		if ctx.Err() != nil {
+			os.Exit(exitAborted)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %s %v\n", title, timeoutError(err, timeout))
+		}
+		if *explicitOnly {
+			packages = filterExplicit(packages)
+		}
+		sections = append(sections, section{title: title, packages: packages})
 	}
 
-	fmt.Printf("\n📦 Installing '%s' from %s...\n", pkg, chosen.Manager)
+	managers := append([]pkgmgr.PackageManager{pm}, pkgmgr.EnabledSources(cfg)...)
+	for _, manager := range managers {
+		icon := "📦"
+		if backend, ok := pkgmgr.LookupBackend(manager.Name()); ok {
+			icon = backend.Icon
+		}
+		addSection(fmt.Sprintf("%s %s Packages:", icon, pkgmgr.DisplayName(manager)), manager.List)
+	}
 
-	var installErr error
-	if chosen.Manager == "flatpak" {
-		flatpakPM := pkgmgr.NewFlatpak()
-		installErr = flatpakPM.Install(chosen.PackageName)
-	} else {
-		installErr = pm.Install(pkg)
+	// JSON export includes every package, each tagged with its source
+	if *asJSON {
+		all := []pkgmgr.Package{}
+		for _, sec := range sections {
+			all = append(all, sec.packages...)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(all); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	if installErr != nil {
-		fmt.Fprintf(os.Stderr, "❌ Failed to install '%s': %v\n", pkg, installErr)
-	} else {
-		fmt.Printf("✅ Successfully installed '%s'\n", pkg)
+	fmt.Println("📋 Installed Packages")
+
+	for i, sec := range sections {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(sec.title)
+		printPackages(sec.packages)
 	}
 }
 
-func removePackage(pkg string, pm pkgmgr.PackageManager, cfg *config.Config) {
-	fmt.Printf("\n🔍 Looking for '%s' to remove...\n", pkg)
-
-	sources := pkgmgr.ResolvePackageForRemove(pkg, pm, cfg.FlatpakEnabled)
-	chosen := pkgmgr.PromptUserChoice(sources, pkg)
+// managerNames returns the display names of package managers
+func managerNames(managers []pkgmgr.PackageManager) []string {
+	names := []string{}
+	for _, manager := range managers {
+		names = append(names, pkgmgr.DisplayName(manager))
+	}
+	return names
+}
 
-	if chosen == nil {
-		fmt.Printf("❌ Package '%s' not found in any source\n", pkg)
+// printPackages prints the first 20 packages of a list
+func printPackages(packages []pkgmgr.Package) {
+	if len(packages) == 0 {
+		fmt.Println("  (none found)")
 		return
 	}
 
-	fmt.Printf("\n📦 Removing '%s' from %s...\n", pkg, chosen.Manager)
-
-	var removeErr error
-	if chosen.Manager == "flatpak" {
-		flatpakPM := pkgmgr.NewFlatpak()
-		removeErr = flatpakPM.Remove(chosen.PackageName)
-	} else {
-		removeErr = pm.Remove(pkg)
+	for i, pkg := range packages {
+		if i >= 20 {
+			fmt.Printf("  ... and %d more\n", len(packages)-20)
+			break
+		}
+		if pkg.InstalledSize > 0 {
+			fmt.Printf("  • %s [%s]\n", pkg, pkgmgr.FormatSize(pkg.InstalledSize))
+		} else {
+			fmt.Printf("  • %s\n", pkg)
+		}
 	}
+}
 
-	if removeErr != nil {
-		fmt.Fprintf(os.Stderr, "❌ Failed to remove '%s': %v\n", pkg, removeErr)
-	} else {
-		fmt.Printf("✅ Successfully removed '%s'\n", pkg)
+// filterExplicit keeps packages that were installed on request
+func filterExplicit(packages []pkgmgr.Package) []pkgmgr.Package {
+	filtered := []pkgmgr.Package{}
+	for _, pkg := range packages {
+		if pkg.Reason != pkgmgr.ReasonDependency {
+			filtered = append(filtered, pkg)
+		}
 	}
+	return filtered
 }
 
-func handleUpdate() {
+func handleAlias(args []string) {
 	mustBeInitialized()
 
-	cfg, pm, err := loadConfigAndPM()
+	if len(args) == 0 {
+		showAliasHelp()
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("🔄 Updating packages...")
-	fmt.Println()
+	switch args[0] {
+	case "add":
+		addCmd := flag.NewFlagSet("alias add", flag.ExitOnError)
+		source := addCmd.String("source", "", "Always use this source, e.g. flatpak")
+		positional, err := parseInterspersed(addCmd, args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error parsing arguments: %v\n", err)
+			os.Exit(1)
+		}
+		if len(positional) < 2 {
+			showAliasHelp()
+			os.Exit(1)
+		}
+		discoverPlugins()
+		if _, ok := pkgmgr.LookupBackend(*source); *source != "" && !ok {
+			fmt.Fprintf(os.Stderr, "❌ Unknown source: %s (see lazylinux backends)\n", *source)
+			os.Exit(1)
+		}
+
+		name := positional[0]
+		if cfg.Aliases == nil {
+			cfg.Aliases = map[string]config.Alias{}
+		}
+		_, replaced := cfg.Aliases[name]
+		cfg.Aliases[name] = config.Alias{Packages: positional[1:], Source: *source}
+		if err := config.SaveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if replaced {
+			fmt.Printf("✅ Updated alias %s → %s\n", name, describeAlias(cfg.Aliases[name]))
+		} else {
+			fmt.Printf("✅ Added alias %s → %s\n", name, describeAlias(cfg.Aliases[name]))
+		}
+
+	case "list":
+		if len(cfg.Aliases) == 0 {
+			fmt.Println("📎 No aliases yet. Add one with: lazylinux alias add <name> <package>...")
+			return
+		}
+		fmt.Println("📎 Aliases:")
+		for _, name := range slices.Sorted(maps.Keys(cfg.Aliases)) {
+			fmt.Printf("  %-12s → %s\n", name, describeAlias(cfg.Aliases[name]))
+		}
 
-	hasErrors := false
+	case "remove":
+		if len(args) < 2 {
+			showAliasHelp()
+			os.Exit(1)
+		}
+		name := args[1]
+		if _, ok := cfg.Aliases[name]; !ok {
+			fmt.Fprintf(os.Stderr, "❌ No alias named %s\n", name)
+			os.Exit(1)
+		}
+		delete(cfg.Aliases, name)
+		if err := config.SaveConfig(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("🗑️  Removed alias %s\n", name)
+
+	default:
+		showAliasHelp()
+		os.Exit(1)
+	}
+}
 
-	// Update native package manager
-	fmt.Printf("🔄 Updating %s packages...\n", getPackageManagerName(pm))
-	err = pm.Update()
+func handleHistory(args []string) {
+	historyCmd := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := historyCmd.Int("limit", 20, "Show at most this many recent transactions")
+	asJSON := historyCmd.Bool("json", false, "Print transactions as JSON")
+
+	ids, err := parseInterspersed(historyCmd, args)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Failed to update %s packages: %v\n", getPackageManagerName(pm), err)
-		hasErrors = true
-	} else {
-		fmt.Printf("✅ %s packages updated\n", getPackageManagerName(pm))
+		fmt.Fprintf(os.Stderr, "❌ Error parsing arguments: %v\n", err)
+		os.Exit(1)
 	}
 
-	fmt.Println()
+	entries, err := journal.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Update Flatpak if enabled
-	if cfg.FlatpakEnabled {
-		fmt.Println("🔄 Updating Flatpak packages...")
-		flatpakPM := pkgmgr.NewFlatpak()
-		err = flatpakPM.Update()
+	// An ID shows that one transaction in full
+	all := entries
+	if len(ids) > 0 {
+		id, err := parseEntryID(ids[0])
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Failed to update Flatpak packages: %v\n", err)
-			hasErrors = true
-		} else {
-			fmt.Println("✅ Flatpak packages updated")
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		entry, err := journal.Find(entries, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		entries = []journal.Entry{*entry}
+		if !*asJSON {
+			printEntry(*entry, journal.UndoneBy(all, entry.ID))
+			return
+		}
+	} else if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
 		}
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("📜 No transactions recorded yet")
+		return
 	}
 
+	fmt.Println("📜 Transaction history:")
 	fmt.Println()
-	if !hasErrors {
-		fmt.Println("✅ All updates complete!")
+	for _, entry := range entries {
+		status := "✅"
+		if !entry.Success {
+			status = "❌"
+		}
+
+		note := fmt.Sprintf("%d packages changed", len(entry.Changes))
+		if len(entry.Changes) == 1 {
+			note = "1 package changed"
+		}
+		if by := journal.UndoneBy(all, entry.ID); by != 0 {
+			note += fmt.Sprintf(", undone by #%d", by)
+		}
+
+		fmt.Printf("  %s #%-4d %s  %-8s %s (%s)\n", status, entry.ID, entry.Time.Format("2006-01-02 15:04"),
+			pkgmgr.SourceDisplayName(entry.Source), entry.Command, note)
+	}
+	fmt.Println()
+	fmt.Println("💡 Run 'lazylinux history <id>' for details or 'lazylinux undo <id>' to reverse one")
+}
+
+// printEntry shows one transaction and every version it changed
+func printEntry(entry journal.Entry, undoneBy int) {
+	fmt.Printf("📜 #%d %s\n", entry.ID, entry.Command)
+	fmt.Printf("  Time:    %s\n", entry.Time.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Source:  %s\n", pkgmgr.SourceDisplayName(entry.Source))
+	if entry.Success {
+		fmt.Println("  Result:  ✅ succeeded")
 	} else {
-		fmt.Println("⚠️  Some updates failed. Check errors above.")
+		fmt.Printf("  Result:  ❌ failed: %s\n", entry.Error)
+	}
+	if entry.Undoes != 0 {
+		fmt.Printf("  Undoes:  #%d\n", entry.Undoes)
+	}
+	if undoneBy != 0 {
+		fmt.Printf("  Undone:  by #%d\n", undoneBy)
+	}
+
+	if len(entry.Changes) == 0 {
+		if entry.Recorded {
+			fmt.Println("  Changes: none")
+		} else {
+			fmt.Println("  Changes: unknown, installed versions couldn't be listed")
+		}
+		return
+	}
+	fmt.Println("  Changes:")
+	for _, change := range entry.Changes {
+		switch {
+		case change.Installed():
+			fmt.Printf("    ➕ %s %s\n", change.Name, change.After)
+		case change.Removed():
+			fmt.Printf("    ➖ %s %s\n", change.Name, change.Before)
+		default:
+			fmt.Printf("    🔁 %s %s → %s\n", change.Name, change.Before, change.After)
+		}
 	}
 }
 
-func handleClean() {
+// parseEntryID accepts a journal entry ID as "12" or "#12"
+func parseEntryID(arg string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid transaction ID %q", arg)
+	}
+	return id, nil
+}
+
+func handleUndo(ctx context.Context) {
 	mustBeInitialized()
 
-	cfg, pm, err := loadConfigAndPM()
+	if len(os.Args) < 3 {
+		fmt.Println("Error: No transaction specified")
+		fmt.Println("Usage: lazylinux undo <id>  (see lazylinux history)")
+		os.Exit(1)
+	}
+
+	id, err := parseEntryID(os.Args[2])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("🧼 Cleaning system...")
-	fmt.Println()
+	entries, err := journal.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	entry, err := journal.Find(entries, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if by := journal.UndoneBy(entries, id); by != 0 {
+		fmt.Fprintf(os.Stderr, "❌ #%d was already undone by #%d\n", id, by)
+		os.Exit(1)
+	}
 
-	// Clean native package manager
-	fmt.Printf("🧹 Cleaning %s...\n", getPackageManagerName(pm))
-	err = pm.Clean()
+	cfg, pm, err := loadConfigAndPM()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Failed to clean native packages: %v\n", err)
-	} else {
-		fmt.Println("✅ Native packages cleaned")
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Clean Flatpak if enabled
-	if cfg.FlatpakEnabled {
-		fmt.Println()
-		fmt.Println("🧹 Cleaning Flatpak...")
-		flatpakPM := pkgmgr.NewFlatpak()
-		err = flatpakPM.Clean()
+	// Undo has to go through the source that made the change
+	manager := pm
+	if entry.Source != pm.Name() {
+		manager, err = pkgmgr.NewBackend(entry.Source, cfg)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Failed to clean Flatpak: %v\n", err)
+			fmt.Fprintf(os.Stderr, "❌ Can't undo #%d: %v\n", id, err)
+			os.Exit(1)
+		}
+	}
+
+	plan := planUndo(*entry)
+	versions, canRestore := manager.(pkgmgr.VersionInstaller)
+	name := pkgmgr.DisplayName(manager)
+
+	fmt.Printf("↩️  Undoing #%d: %s (%s)\n", id, entry.Command, name)
+	if len(plan.remove) > 0 {
+		fmt.Printf("  ➖ Remove: %s\n", strings.Join(plan.remove, ", "))
+	}
+	if len(plan.reinstall) > 0 {
+		fmt.Printf("  ➕ Reinstall: %s\n", formatVersions(plan.reinstall, canRestore))
+	}
+	if len(plan.revert) > 0 {
+		if canRestore {
+			fmt.Printf("  🔁 Restore: %s\n", formatVersions(plan.revert, true))
 		} else {
-			fmt.Println("✅ Flatpak cleaned")
+			fmt.Printf("  ⚠️  %s can't install older versions, leaving %s as they are\n", name, strings.Join(sortedNames(plan.revert), ", "))
+			plan.revert = nil
 		}
 	}
+	if len(plan.remove)+len(plan.reinstall)+len(plan.revert) == 0 {
+		fmt.Printf("✨ Nothing to undo for #%d\n", id)
+		return
+	}
 
-	fmt.Println()
-	fmt.Println("✅ System cleaned!")
-}
+	timeouts := cfg.Timeouts.WithDefaults()
+	undoCtx, cancel := context.WithTimeout(ctx, timeouts.Install)
+	defer cancel()
 
-func handleList() {
-	mustBeInitialized()
+	undo := journal.Entry{Action: "undo", Undoes: id, Requested: entry.Requested}
+	startHooks("undo", entry.Requested)
+	err = runTransaction(undoCtx, cfg, manager, &undo, func() error {
+		fmt.Println()
+		if len(plan.remove) > 0 {
+			if err := manager.Remove(undoCtx, plan.remove...); err != nil {
+				return err
+			}
+		}
+
+		// Without a version to go back to, the latest has to do
+		restore, latest := map[string]string{}, []string{}
+		maps.Copy(restore, plan.revert)
+		for _, name := range sortedNames(plan.reinstall) {
+			if version := plan.reinstall[name]; canRestore && version != "" {
+				restore[name] = version
+			} else {
+				latest = append(latest, name)
+			}
+		}
+
+		if len(restore) > 0 {
+			if err := versions.InstallVersions(undoCtx, restore); err != nil {
+				return err
+			}
+		}
+		if len(latest) > 0 {
+			return manager.Install(undoCtx, latest...)
+		}
+		return nil
+	})
+	finishHooks()
 
-	cfg, pm, err := loadConfigAndPM()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
-		os.Exit(1)
+		if ctx.Err() != nil {
+			os.Exit(exitAborted)
+		}
+		fmt.Fprintf(os.Stderr, "❌ Failed to undo #%d: %v\n", id, timeoutError(err, timeouts.Install))
+		printAdvice(err)
+		os.Exit(exitCodeFor(err))
 	}
 
-	fmt.Println("📋 Installed Packages")
+	fmt.Printf("✅ Undid #%d\n", id)
+}
 
-	// List native packages
-	fmt.Printf("📦 %s Packages:\n", getPackageManagerName(pm))
-	nativeList, err := pm.List()
-	if err == nil && len(nativeList) > 0 {
-		for i, pkg := range nativeList {
-			if i >= 20 {
-				fmt.Printf("  ... and %d more\n", len(nativeList)-20)
-				break
-			}
-			fmt.Printf("  • %s\n", pkg)
+// undoPlan is what reverses a journal entry
+type undoPlan struct {
+	remove    []string          // Packages the entry installed
+	reinstall map[string]string // Packages the entry removed, with their version
+	revert    map[string]string // Packages the entry upgraded or downgraded, with the old version
+}
+
+// planUndo works out how to reverse entry from the versions it changed.
+// Entries whose versions couldn't be listed fall back to the requested
+// packages; an entry that was listed and changed nothing needs nothing.
+func planUndo(entry journal.Entry) undoPlan {
+	plan := undoPlan{reinstall: map[string]string{}, revert: map[string]string{}}
+
+	for _, change := range entry.Changes {
+		switch {
+		case change.Installed():
+			plan.remove = append(plan.remove, change.Name)
+		case change.Removed():
+			plan.reinstall[change.Name] = change.Before
+		default:
+			plan.revert[change.Name] = change.Before
 		}
-	} else {
-		fmt.Println("  (none found)")
 	}
-	fmt.Println()
 
-	// List Flatpak packages if enabled
-	if cfg.FlatpakEnabled {
-		fmt.Println("🎨 Flatpak Packages:")
-		flatpakPM := pkgmgr.NewFlatpak()
-		flatpakList, err := flatpakPM.List()
-		if err == nil && len(flatpakList) > 0 {
-			for i, pkg := range flatpakList {
-				if i >= 20 {
-					fmt.Printf("  ... and %d more\n", len(flatpakList)-20)
-					break
-				}
-				fmt.Printf("  • %s\n", pkg)
+	if !entry.Recorded && len(entry.Changes) == 0 && entry.Success {
+		switch entry.Action {
+		case "install":
+			plan.remove = entry.Requested
+		case "remove":
+			for _, name := range entry.Requested {
+				plan.reinstall[name] = ""
 			}
-		} else {
-			fmt.Println("  (none found)")
 		}
 	}
+	return plan
+}
+
+// formatVersions lists packages as "name version", leaving versions out
+// when they won't be used
+func formatVersions(versions map[string]string, withVersions bool) string {
+	parts := []string{}
+	for _, name := range sortedNames(versions) {
+		if withVersions && versions[name] != "" {
+			name += " " + versions[name]
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedNames returns the package names in versions, sorted
+func sortedNames(versions map[string]string) []string {
+	return slices.Sorted(maps.Keys(versions))
 }
 
 func handleWebApp(args []string) {
@@ -390,34 +1867,55 @@ func handleWebApp(args []string) {
 	}
 }
 
+// pluginsOnce guards plugin discovery, which spawns every plugin
+var pluginsOnce sync.Once
+
+// discoverPlugins lets external backends found on PATH join the built-in
+// ones. Only commands that use backends call it, so the rest don't wait
+// for plugins to start.
+func discoverPlugins() {
+	pluginsOnce.Do(func() {
+		for _, err := range pkgmgr.DiscoverPlugins() {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+	})
+}
+
 func loadPackageManager() (pkgmgr.PackageManager, error) {
+	discoverPlugins()
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return nil, fmt.Errorf("could not load config: %v", err)
 	}
 
-	switch cfg.PackageManager {
-	case "dnf":
-		return pkgmgr.NewDNF(), nil
-	case "apt":
-		return pkgmgr.NewAPT(), nil
-	case "pacman":
-		return pkgmgr.NewPacman(), nil
-	default:
-		return nil, fmt.Errorf("unknown package manager: %s", cfg.PackageManager)
+	if err := pkgmgr.SetEscalationTool(cfg.Escalation); err != nil {
+		return nil, err
 	}
+	pkgmgr.SetLockWait(cfg.Timeouts.WithDefaults().Lock, globals.noWait)
+	pkgmgr.SetCacheTTL(cfg.CacheTTL)
+
+	return pkgmgr.NewBackend(cfg.PackageManager, cfg)
 }
 
-func getPackageManagerName(pm pkgmgr.PackageManager) string {
-	switch pm.(type) {
-	case *pkgmgr.DNF:
-		return "DNF"
-	case *pkgmgr.APT:
-		return "APT"
-	case *pkgmgr.Pacman:
-		return "Pacman"
-	default:
-		return "unknown"
+func handleBackends() {
+	discoverPlugins()
+
+	fmt.Println("🔌 Registered backends:")
+	fmt.Println()
+
+	for _, backend := range pkgmgr.Backends() {
+		kind := "source"
+		if backend.Native {
+			kind = "native"
+		}
+
+		status := "❌"
+		if backend.Detect() {
+			status = "✅"
+		}
+
+		fmt.Printf("  %s %-8s %-8s %-7s %s\n", status, backend.Name, backend.DisplayName, kind, backend.Capabilities)
 	}
 }
 
@@ -429,9 +1927,20 @@ func showHelp() {
 	fmt.Println("  install <package>...   - Install packages")
 	fmt.Println("  remove <package>...    - Remove packages")
 	fmt.Println("  update                 - Update all packages")
+	fmt.Println("  refresh                - Rebuild the cached package indexes")
 	fmt.Println("  clean                  - Clean cache and remove orphaned packages")
-	fmt.Println("  list                   - List all installed packages")
+	fmt.Println("  list                   - List installed packages (--json, --explicit)")
+	fmt.Println("  info <package>         - Show package details")
+	fmt.Println("  search <term>          - Search every source (--source, --limit, --json)")
+	fmt.Println("  alias                  - Manage package aliases (add, list, remove)")
+	fmt.Println("  history [id]           - Show past transactions (--limit, --json)")
+	fmt.Println("  undo <id>              - Reverse a transaction from the history")
+	fmt.Println("  backends               - List supported package managers")
 	fmt.Println("  webapp                 - Manage web applications")
+	fmt.Println()
+	fmt.Println("Global options:")
+	fmt.Println("  --dry-run              - Show what install, remove, update or clean would change")
+	fmt.Println("  --no-wait              - Fail instead of waiting for a busy package manager or lazylinux run")
 }
 
 func showWebAppHelp() {
@@ -443,3 +1952,11 @@ func showWebAppHelp() {
 	fmt.Println("  -l                    List all webapps")
 	fmt.Println("  -o <name>             Open a webapp")
 }
+
+func showAliasHelp() {
+	fmt.Println("Usage: lazylinux alias <command>")
+	fmt.Println("Commands:")
+	fmt.Println("  add <name> [--source <source>] <package>...   Add or replace an alias")
+	fmt.Println("  list                                          List aliases")
+	fmt.Println("  remove <name>                                 Remove an alias")
+}
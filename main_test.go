@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VaibhavPrakash0503/lazylinux/internal/config"
+	"github.com/VaibhavPrakash0503/lazylinux/internal/hooks"
+	"github.com/VaibhavPrakash0503/lazylinux/internal/journal"
+)
+
+func TestPlanUndo(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry journal.Entry
+		want  undoPlan
+	}{
+		{
+			name: "install removes what was installed",
+			entry: journal.Entry{
+				Action: "install", Requested: []string{"htop"}, Recorded: true, Success: true,
+				Changes: []journal.Change{
+					{Name: "htop", After: "3.3.0-3.fc40"},
+					{Name: "hwloc-libs", After: "2.10.0-3.fc40"},
+				},
+			},
+			want: undoPlan{remove: []string{"htop", "hwloc-libs"}},
+		},
+		{
+			name: "remove reinstalls the removed version",
+			entry: journal.Entry{
+				Action: "remove", Requested: []string{"nano"}, Recorded: true, Success: true,
+				Changes: []journal.Change{{Name: "nano", Before: "7.2-6.fc40"}},
+			},
+			want: undoPlan{reinstall: map[string]string{"nano": "7.2-6.fc40"}},
+		},
+		{
+			name: "update reverts to the old versions",
+			entry: journal.Entry{
+				Action: "update", Recorded: true, Success: true,
+				Changes: []journal.Change{
+					{Name: "curl", Before: "8.6.0-8.fc40", After: "8.6.0-10.fc40"},
+					{Name: "gpm-libs", After: "1.20.7-46.fc40"},
+				},
+			},
+			want: undoPlan{
+				remove: []string{"gpm-libs"},
+				revert: map[string]string{"curl": "8.6.0-8.fc40"},
+			},
+		},
+		{
+			name: "recorded install that changed nothing",
+			entry: journal.Entry{
+				Action: "install", Requested: []string{"vim"}, Recorded: true, Success: true,
+			},
+			want: undoPlan{},
+		},
+		{
+			name: "unrecorded install falls back to the request",
+			entry: journal.Entry{
+				Action: "install", Requested: []string{"org.gimp.GIMP"}, Success: true,
+			},
+			want: undoPlan{remove: []string{"org.gimp.GIMP"}},
+		},
+		{
+			name: "unrecorded remove reinstalls any version",
+			entry: journal.Entry{
+				Action: "remove", Requested: []string{"org.gimp.GIMP"}, Success: true,
+			},
+			want: undoPlan{reinstall: map[string]string{"org.gimp.GIMP": ""}},
+		},
+		{
+			name: "unrecorded failure",
+			entry: journal.Entry{
+				Action: "install", Requested: []string{"htop"}, Error: "dnf install failed",
+			},
+			want: undoPlan{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planUndo(tt.entry)
+			if tt.want.reinstall == nil {
+				tt.want.reinstall = map[string]string{}
+			}
+			if tt.want.revert == nil {
+				tt.want.revert = map[string]string{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planUndo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatVersions(t *testing.T) {
+	versions := map[string]string{"nano": "7.2-6.fc40", "curl": "8.6.0-8.fc40", "org.gimp.GIMP": ""}
+
+	if got, want := formatVersions(versions, true), "curl 8.6.0-8.fc40, nano 7.2-6.fc40, org.gimp.GIMP"; got != want {
+		t.Errorf("formatVersions(true) = %q, want %q", got, want)
+	}
+	if got, want := formatVersions(versions, false), "curl, nano, org.gimp.GIMP"; got != want {
+		t.Errorf("formatVersions(false) = %q, want %q", got, want)
+	}
+}
+
+func TestCommandHooksRunOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	out := filepath.Join(t.TempDir(), "out")
+	t.Setenv("HOOK_OUT", out)
+
+	cfg := &config.Config{Hooks: map[string][]string{
+		"pre-install":  {`echo pre >> "$HOOK_OUT"`},
+		"post-install": {`cat >> "$HOOK_OUT"`},
+	}}
+
+	// An install from two sources
+	startHooks("install", []string{"htop", "org.gimp.GIMP"})
+	entries := []journal.Entry{
+		{Source: "dnf", Requested: []string{"htop"}, Success: true, Changes: []journal.Change{{Name: "htop", After: "3.3.0-3.fc40"}}},
+		{Source: "flatpak", Requested: []string{"org.gimp.GIMP"}, Error: "flatpak install failed"},
+	}
+	for _, entry := range entries {
+		if err := commandHooks.before(context.Background(), cfg); err != nil {
+			t.Fatal(err)
+		}
+		commandHooks.record(entry)
+	}
+	finishHooks()
+	finishHooks()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pre, post, _ := strings.Cut(string(data), "\n")
+	if pre != "pre" {
+		t.Errorf("pre-hooks wrote %q, want them to run once", data)
+	}
+
+	var event hooks.Event
+	if err := json.Unmarshal([]byte(post), &event); err != nil {
+		t.Fatalf("post-hooks wrote %q, want them to run once: %v", post, err)
+	}
+	want := []hooks.Transaction{
+		{Source: "dnf", Packages: []string{"htop"}, Result: "success", Changes: entries[0].Changes},
+		{Source: "flatpak", Packages: []string{"org.gimp.GIMP"}, Result: "failure", Error: "flatpak install failed"},
+	}
+	if event.Result != "failure" || !reflect.DeepEqual(event.Transactions, want) {
+		t.Errorf("post-hook input = %+v, want a failure with both transactions", event)
+	}
+}
+
+func TestCommandHooksPreFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := &config.Config{Hooks: map[string][]string{"pre-update": {"exit 1"}}}
+
+	startHooks("update", nil)
+	for range 2 {
+		if err := commandHooks.before(context.Background(), cfg); err == nil {
+			t.Error("before() succeeded after a failing pre-hook")
+		}
+	}
+}
+
+func TestExpandAliases(t *testing.T) {
+	cfg := &config.Config{Aliases: map[string]config.Alias{
+		"k8s":     {Packages: []string{"kubectl", "helm"}},
+		"browser": {Packages: []string{"org.mozilla.firefox"}, Source: "flatpak"},
+		"broken":  {Packages: []string{"ripgrep"}, Source: "homebrew"},
+	}}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []packageRequest
+		wantErr bool
+	}{
+		{
+			name: "no aliases",
+			args: []string{"vim", "htop"},
+			want: []packageRequest{{name: "vim"}, {name: "htop"}},
+		},
+		{
+			name: "expanded in place",
+			args: []string{"vim", "k8s", "htop"},
+			want: []packageRequest{{name: "vim"}, {name: "kubectl"}, {name: "helm"}, {name: "htop"}},
+		},
+		{
+			name: "fixed source",
+			args: []string{"browser"},
+			want: []packageRequest{{name: "org.mozilla.firefox", source: "flatpak"}},
+		},
+		{
+			name:    "unknown source",
+			args:    []string{"vim", "broken"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandAliases(cfg, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandAliases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandAliases() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAliasCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir()) // No plugins
+	cfg := &config.Config{PackageManager: "dnf", Hooks: map[string][]string{"post-install": {"true"}}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		args []string
+		want map[string]config.Alias
+	}{
+		{
+			args: []string{"add", "k8s", "kubectl", "helm"},
+			want: map[string]config.Alias{"k8s": {Packages: []string{"kubectl", "helm"}}},
+		},
+		{
+			args: []string{"add", "browser", "org.mozilla.firefox", "--source", "flatpak"},
+			want: map[string]config.Alias{
+				"k8s":     {Packages: []string{"kubectl", "helm"}},
+				"browser": {Packages: []string{"org.mozilla.firefox"}, Source: "flatpak"},
+			},
+		},
+		{
+			args: []string{"add", "k8s", "kubectl", "helm", "k9s"},
+			want: map[string]config.Alias{
+				"k8s":     {Packages: []string{"kubectl", "helm", "k9s"}},
+				"browser": {Packages: []string{"org.mozilla.firefox"}, Source: "flatpak"},
+			},
+		},
+		{
+			args: []string{"list"},
+			want: map[string]config.Alias{
+				"k8s":     {Packages: []string{"kubectl", "helm", "k9s"}},
+				"browser": {Packages: []string{"org.mozilla.firefox"}, Source: "flatpak"},
+			},
+		},
+		{
+			args: []string{"remove", "browser"},
+			want: map[string]config.Alias{"k8s": {Packages: []string{"kubectl", "helm", "k9s"}}},
+		},
+	}
+
+	for _, step := range steps {
+		handleAlias(step.args)
+
+		got, err := config.LoadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Aliases, step.want) {
+			t.Errorf("aliases after alias %s = %+v, want %+v", strings.Join(step.args, " "), got.Aliases, step.want)
+		}
+		if !reflect.DeepEqual(got.Hooks, cfg.Hooks) || got.PackageManager != "dnf" {
+			t.Errorf("alias %s changed the rest of the config: %+v", strings.Join(step.args, " "), got)
+		}
+	}
+}